@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	task "github.com/vikbert/taskr/v3"
+	"github.com/vikbert/taskr/v3/args"
+)
+
+// remoteCommands are the subcommands of "task remote". Any other argument
+// after "remote" is treated as a task name so that tasks called "remote" can
+// still be run.
+var remoteCommands = []string{"list", "update", "diff", "prune"}
+
+// getRemoteCommand returns the remote subcommand and its arguments if Task was
+// called as "task remote <command> [args...]".
+func getRemoteCommand() (string, []string, bool) {
+	cliArgs, _, err := args.Get()
+	if err != nil || len(cliArgs) < 2 || cliArgs[0] != "remote" {
+		return "", nil, false
+	}
+	if !slices.Contains(remoteCommands, cliArgs[1]) {
+		return "", nil, false
+	}
+	return cliArgs[1], cliArgs[2:], true
+}
+
+func runRemoteCommand(e *task.Executor, command string, commandArgs []string) error {
+	if command != "update" && len(commandArgs) > 0 {
+		return fmt.Errorf("task: Too many arguments for `task remote %s`", command)
+	}
+	switch command {
+	case "list":
+		return e.ListRemotes()
+	case "update":
+		return e.UpdateRemotes(commandArgs...)
+	case "diff":
+		return e.DiffRemotes(context.Background())
+	default:
+		return e.PruneRemotes()
+	}
+}
@@ -45,12 +45,16 @@ func main() {
 	os.Exit(errors.CodeOk)
 }
 
-// emitCIErrorAnnotation emits an error annotation for supported CI providers.
+// emitCIErrorAnnotation emits an error annotation for supported CI providers,
+// unless the output style already did.
 func emitCIErrorAnnotation(err error) {
 	if isGA, _ := strconv.ParseBool(os.Getenv("GITHUB_ACTIONS")); !isGA {
 		return
 	}
 	if e, ok := err.(*errors.TaskRunError); ok {
+		if e.Annotated {
+			return
+		}
 		fmt.Fprintf(os.Stdout, "::error title=Task '%s' failed::%v\n", e.TaskName, e.Err)
 		return
 	}
@@ -129,7 +133,15 @@ func run() error {
 	e := task.NewExecutor(
 		flags.WithFlags(),
 		task.WithVersionCheck(true),
+		task.WithCIOutput(true),
 	)
+
+	if experiments.RemoteTaskfiles.Enabled() {
+		if command, commandArgs, ok := getRemoteCommand(); ok {
+			return runRemoteCommand(e, command, commandArgs)
+		}
+	}
+
 	if err := e.Setup(); err != nil {
 		return err
 	}
@@ -139,6 +151,11 @@ func run() error {
 		return os.RemoveAll(cachePath)
 	}
 
+	// The lockfile is written while reading the Taskfiles
+	if flags.Lock {
+		return nil
+	}
+
 	listOptions := task.NewListOptions(
 		flags.List,
 		flags.ListAll,
@@ -173,7 +190,7 @@ func run() error {
 	}
 
 	// Merge CLI variables first (e.g. FOO=bar) so they take priority over Taskfile defaults
-	e.Taskfile.Vars.Merge(globals, nil)
+	e.Taskfile.Vars.Override(globals)
 
 	// Then ReverseMerge special variables so they're available for templating
 	cliArgsPostDashQuoted, err := args.ToQuotedString(cliArgsPostDash)
@@ -27,43 +27,96 @@ type Compiler struct {
 	TaskfileVars *ast.Vars
 
 	Logger *logger.Logger
+	// Strict makes the templates of every variable fail when they use
+	// undefined variables, not only those of strict tasks.
+	Strict bool
+	// Profile is the name of the selected profile, if any
+	Profile string
+	// ProfileVars are the variables of the selected profile. They are already
+	// part of TaskfileVars, but included tasks carry a copy of the variables
+	// of their parent Taskfile that would shadow them otherwise.
+	ProfileVars *ast.Vars
+	// CacheDir is the directory where the results of the dynamic variables
+	// with a cache are kept between runs
+	CacheDir string
+	// SecretProviders resolve the secret variables by the name of their
+	// provider
+	SecretProviders map[string]SecretProvider
 
 	dynamicCache   map[string]string
 	muDynamicCache sync.Mutex
+
+	secretCache   map[secretCacheKey]*secretCacheEntry
+	muSecretCache sync.Mutex
+}
+
+// secretCacheKey identifies a secret. Providers resolve relative sources in
+// the directory of the secret, so the same secret can differ between
+// directories.
+type secretCacheKey struct {
+	ast.Secret
+	Dir string
+}
+
+// secretCacheEntry is a secret being resolved, or resolved already once done
+// is closed
+type secretCacheEntry struct {
+	done  chan struct{}
+	value string
+	err   error
 }
 
 func (c *Compiler) GetTaskfileVariables() (*ast.Vars, error) {
-	return c.getVariables(nil, nil, true)
+	return c.getVariables(nil, nil, true, nil)
 }
 
 func (c *Compiler) GetVariables(t *ast.Task, call *Call) (*ast.Vars, error) {
-	return c.getVariables(t, call, true)
+	return c.getVariables(t, call, true, nil)
 }
 
 func (c *Compiler) FastGetVariables(t *ast.Task, call *Call) (*ast.Vars, error) {
-	return c.getVariables(t, call, false)
+	return c.getVariables(t, call, false, nil)
 }
 
-func (c *Compiler) getVariables(t *ast.Task, call *Call, evaluateShVars bool) (*ast.Vars, error) {
+// ExplainVariables resolves the variables of the task like [GetVariables] and
+// also returns the values that each variable took in every layer, in the order
+// they were applied. The last origin of a variable is its final value.
+func (c *Compiler) ExplainVariables(t *ast.Task, call *Call) (*ast.Vars, VarOrigins, error) {
+	origins := VarOrigins{}
+	vars, err := c.getVariables(t, call, true, origins)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vars, origins, nil
+}
+
+func (c *Compiler) getVariables(t *ast.Task, call *Call, evaluateShVars bool, origins VarOrigins) (*ast.Vars, error) {
 	result := env.GetEnviron()
+	if origins != nil {
+		for k, v := range result.All() {
+			origins.record(k, VarLayerEnvironment, v, v.Value)
+		}
+	}
 	specialVars, err := c.getSpecialVars(t, call)
 	if err != nil {
 		return nil, err
 	}
 	for k, v := range specialVars {
 		result.Set(k, ast.Var{Value: v})
+		origins.record(k, VarLayerSpecial, ast.Var{}, v)
 	}
 
+	strict := c.Strict || (t != nil && t.Strict)
 	getRangeFunc := func(dir string) func(k string, v ast.Var) error {
 		return func(k string, v ast.Var) error {
-			cache := &templater.Cache{Vars: result}
+			cache := &templater.Cache{Vars: result, Strict: strict, Dir: dir}
 			// Replace values
 			newVar := templater.ReplaceVar(v, cache)
 			// If the variable should not be evaluated, but is nil, set it to an empty string
 			// This stops empty interface errors when using the templater to replace values later
 			// Preserve the Sh field so it can be displayed in summary
 			if !evaluateShVars && newVar.Value == nil {
-				result.Set(k, ast.Var{Value: "", Sh: newVar.Sh})
+				result.Set(k, ast.Var{Value: "", Sh: newVar.Sh, Secret: newVar.Secret})
 				return nil
 			}
 			// If the variable should not be evaluated and it is set, we can set it and return
@@ -75,6 +128,14 @@ func (c *Compiler) getVariables(t *ast.Task, call *Call, evaluateShVars bool) (*
 			if err := cache.Err(); err != nil {
 				return err
 			}
+			if newVar.Secret != nil {
+				static, err := c.HandleSecretVar(newVar, dir, env.GetFromVars(result))
+				if err != nil {
+					return err
+				}
+				result.Set(k, ast.Var{Value: static})
+				return nil
+			}
 			// If the variable is already set, we can set it and return
 			if newVar.Value != nil || newVar.Sh == nil {
 				result.Set(k, ast.Var{Value: newVar.Value})
@@ -89,13 +150,27 @@ func (c *Compiler) getVariables(t *ast.Task, call *Call, evaluateShVars bool) (*
 			return nil
 		}
 	}
+	// track records the origin of each variable after it is resolved
+	track := func(layer string, rangeFunc func(k string, v ast.Var) error) func(k string, v ast.Var) error {
+		if origins == nil {
+			return rangeFunc
+		}
+		return func(k string, v ast.Var) error {
+			if err := rangeFunc(k, v); err != nil {
+				return err
+			}
+			value, _ := result.Get(k)
+			origins.record(k, layer, v, value.Value)
+			return nil
+		}
+	}
 	rangeFunc := getRangeFunc(c.Dir)
 
 	var taskRangeFunc func(k string, v ast.Var) error
 	if t != nil {
 		// NOTE(@andreynering): We're manually joining these paths here because
 		// this is the raw task, not the compiled one.
-		cache := &templater.Cache{Vars: result}
+		cache := &templater.Cache{Vars: result, Strict: strict, Dir: c.Dir}
 		dir := templater.Replace(t.Dir, cache)
 		if err := cache.Err(); err != nil {
 			return nil, err
@@ -105,26 +180,33 @@ func (c *Compiler) getVariables(t *ast.Task, call *Call, evaluateShVars bool) (*
 	}
 
 	for k, v := range c.TaskfileEnv.All() {
-		if err := rangeFunc(k, v); err != nil {
+		if err := track(VarLayerTaskfileEnv, rangeFunc)(k, v); err != nil {
 			return nil, err
 		}
 	}
 	for k, v := range c.TaskfileVars.All() {
-		if err := rangeFunc(k, v); err != nil {
+		if err := track(VarLayerTaskfileVars, rangeFunc)(k, v); err != nil {
 			return nil, err
 		}
 	}
 	if t != nil {
 		for k, v := range t.IncludeVars.All() {
-			if err := rangeFunc(k, v); err != nil {
+			if err := track(VarLayerIncludeVars, rangeFunc)(k, v); err != nil {
 				return nil, err
 			}
 		}
 		for k, v := range t.IncludedTaskfileVars.All() {
-			if err := taskRangeFunc(k, v); err != nil {
+			if err := track(VarLayerIncludedTaskfileVars, taskRangeFunc)(k, v); err != nil {
 				return nil, err
 			}
 		}
+		if t.IncludedTaskfileVars != nil {
+			for k, v := range c.ProfileVars.All() {
+				if err := track(VarLayerProfile, rangeFunc)(k, v); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
 	if t == nil || call == nil {
@@ -132,12 +214,12 @@ func (c *Compiler) getVariables(t *ast.Task, call *Call, evaluateShVars bool) (*
 	}
 
 	for k, v := range call.Vars.All() {
-		if err := rangeFunc(k, v); err != nil {
+		if err := track(VarLayerCall, rangeFunc)(k, v); err != nil {
 			return nil, err
 		}
 	}
 	for k, v := range t.Vars.All() {
-		if err := taskRangeFunc(k, v); err != nil {
+		if err := track(VarLayerTask, taskRangeFunc)(k, v); err != nil {
 			return nil, err
 		}
 	}
@@ -166,6 +248,16 @@ func (c *Compiler) HandleDynamicVar(v ast.Var, dir string, e []string) (string,
 		dir = v.Dir
 	}
 
+	// Commands that contain secrets are never cached on disk
+	persist := v.Cache != nil && c.Redact(*v.Sh) == *v.Sh
+	if persist {
+		if result, ok := c.readCachedVar(v, dir, e); ok {
+			c.dynamicCache[*v.Sh] = result
+			c.Logger.VerboseErrf(logger.Magenta, "task: dynamic variable: %q cached result: %q\n", *v.Sh, result)
+			return result, nil
+		}
+	}
+
 	var stdout bytes.Buffer
 	opts := &execext.RunCommandOptions{
 		Command: *v.Sh,
@@ -184,11 +276,99 @@ func (c *Compiler) HandleDynamicVar(v ast.Var, dir string, e []string) (string,
 	result = strings.TrimSuffix(result, "\n")
 
 	c.dynamicCache[*v.Sh] = result
-	c.Logger.VerboseErrf(logger.Magenta, "task: dynamic variable: %q result: %q\n", *v.Sh, result)
+	c.Logger.VerboseErrf(logger.Magenta, "task: dynamic variable: %q result: %q\n", c.Redact(*v.Sh), c.Redact(result))
+
+	// Neither are results that contain secrets
+	if persist && c.Redact(result) == result {
+		if err := c.writeCachedVar(v, dir, e, result); err != nil {
+			c.Logger.VerboseErrf(logger.Yellow, "task: failed to cache dynamic variable %q: %v\n", *v.Sh, err)
+		}
+	}
 
 	return result, nil
 }
 
+// HandleSecretVar resolves the value of a secret variable with its provider.
+// Secrets are cached in memory only, so that each of them is resolved at most
+// once per run.
+func (c *Compiler) HandleSecretVar(v ast.Var, dir string, e []string) (string, error) {
+	if v.Secret == nil {
+		return "", nil
+	}
+	// Secrets of included Taskfiles are resolved in their directory
+	if v.Dir != "" {
+		dir = v.Dir
+	}
+	key := secretCacheKey{Secret: *v.Secret, Dir: dir}
+
+	// The provider runs without holding the lock, so that the output of the
+	// commands can be redacted meanwhile. Concurrent calls for the same secret
+	// wait for the first one instead.
+	c.muSecretCache.Lock()
+	if entry, ok := c.secretCache[key]; ok {
+		c.muSecretCache.Unlock()
+		<-entry.done
+		return entry.value, entry.err
+	}
+	if c.secretCache == nil {
+		c.secretCache = make(map[secretCacheKey]*secretCacheEntry)
+	}
+	entry := &secretCacheEntry{done: make(chan struct{})}
+	c.secretCache[key] = entry
+	c.muSecretCache.Unlock()
+
+	value, err := c.resolveSecret(v.Secret, dir, e)
+
+	c.muSecretCache.Lock()
+	entry.value, entry.err = value, err
+	// Failures are not cached, so that they can be retried, e.g. in watch mode
+	if err != nil {
+		delete(c.secretCache, key)
+	}
+	c.muSecretCache.Unlock()
+	close(entry.done)
+
+	if err == nil {
+		c.Logger.VerboseErrf(logger.Magenta, "task: secret variable from %s resolved\n", v.Secret.Provider)
+	}
+	return value, err
+}
+
+func (c *Compiler) resolveSecret(secret *ast.Secret, dir string, e []string) (string, error) {
+	provider, ok := c.SecretProviders[secret.Provider]
+	if !ok {
+		return "", fmt.Errorf(`task: Secret provider %q does not exist`, secret.Provider)
+	}
+	result, err := provider.ResolveSecret(context.Background(), &SecretRequest{
+		Source: secret.Source,
+		Key:    secret.Key,
+		Dir:    dir,
+		Env:    e,
+	})
+	if err != nil {
+		return "", fmt.Errorf(`task: Secret from %s %q failed: %w`, secret.Provider, c.Redact(secret.Source), err)
+	}
+	return result, nil
+}
+
+// Redact replaces the values of the secrets that were resolved so far in the
+// given string
+func (c *Compiler) Redact(s string) string {
+	c.muSecretCache.Lock()
+	defer c.muSecretCache.Unlock()
+
+	return c.redact(s)
+}
+
+func (c *Compiler) redact(s string) string {
+	for _, secret := range c.secretCache {
+		if secret.value != "" {
+			s = strings.ReplaceAll(s, secret.value, "*****")
+		}
+	}
+	return s
+}
+
 // ResetCache clear the dynamic variables cache
 func (c *Compiler) ResetCache() {
 	c.muDynamicCache.Lock()
@@ -204,6 +384,7 @@ func (c *Compiler) getSpecialVars(t *ast.Task, call *Call) (map[string]string, e
 		"ROOT_DIR":         c.Dir,
 		"USER_WORKING_DIR": c.UserWorkingDir,
 		"TASK_VERSION":     version.GetVersion(),
+		"TASK_PROFILE":     c.Profile,
 	}
 	if t != nil {
 		allVars["TASK"] = t.Task
@@ -1,5 +1,10 @@
 package task
 
+import (
+	"context"
+	"slices"
+)
+
 func (e *Executor) acquireConcurrencyLimit() func() {
 	if e.concurrencySemaphore == nil {
 		return emptyFunc
@@ -22,4 +27,70 @@ func (e *Executor) releaseConcurrencyLimit() func() {
 	}
 }
 
+// resourceClaim is the slots of the resource pools held by a task invocation
+type resourceClaim struct {
+	names []string
+	held  bool
+}
+
+// acquireResources waits until a slot of every resource pool used by the given
+// task is available and claims it. Pools are always acquired in the same order
+// to prevent deadlocks between tasks using several of them, and the
+// concurrency slot of the task is given up while waiting, so other tasks are
+// able to run meanwhile.
+func (e *Executor) acquireResources(ctx context.Context, uses []string) (*resourceClaim, error) {
+	claim := &resourceClaim{names: slices.Compact(slices.Sorted(slices.Values(uses)))}
+	if len(claim.names) == 0 {
+		return claim, nil
+	}
+	reacquire := e.releaseConcurrencyLimit()
+	defer reacquire()
+	if err := e.claimResources(ctx, claim); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (e *Executor) claimResources(ctx context.Context, claim *resourceClaim) error {
+	for i, name := range claim.names {
+		select {
+		case e.resourceSemaphores[name] <- struct{}{}:
+		case <-ctx.Done():
+			e.releaseResourcesNow(claim.names[:i])
+			return ctx.Err()
+		}
+	}
+	claim.held = true
+	return nil
+}
+
+// releaseResources releases the slots of the given claim, if they are still
+// held.
+func (e *Executor) releaseResources(claim *resourceClaim) {
+	if claim == nil || !claim.held {
+		return
+	}
+	e.releaseResourcesNow(claim.names)
+	claim.held = false
+}
+
+// suspendResources temporarily releases the resource pools used by a task, so
+// tasks it calls are able to use them as well. The returned function claims
+// them again, unless the context is cancelled first.
+func (e *Executor) suspendResources(claim *resourceClaim) func(ctx context.Context) error {
+	if claim == nil || !claim.held {
+		return func(context.Context) error { return nil }
+	}
+	e.releaseResources(claim)
+	return func(ctx context.Context) error {
+		return e.claimResources(ctx, claim)
+	}
+}
+
+func (e *Executor) releaseResourcesNow(names []string) {
+	for _, name := range names {
+		<-e.resourceSemaphores[name]
+	}
+}
+
 func emptyFunc() {}
@@ -27,6 +27,11 @@ const (
 	CodeTaskfileInvalid
 	CodeTaskfileCycle
 	CodeTaskfileDoesNotMatchChecksum
+	CodeTaskfileNotLocked
+	CodeTaskfileSignatureInvalid
+	CodeTaskfileMissingRequiredVars
+	CodeTaskfileNotAllowedVars
+	CodeTaskfileProfileNotFound
 )
 
 // Task related exit codes
@@ -39,6 +44,8 @@ const (
 	CodeTaskCancelled
 	CodeTaskMissingRequiredVars
 	CodeTaskNotAllowedVars
+	CodeTaskUndefinedVar
+	CodeTaskProfileRequired
 )
 
 // TaskError extends the standard error interface with a Code method. This code will
@@ -36,6 +36,9 @@ func (err *TaskNotFoundError) Code() int {
 type TaskRunError struct {
 	TaskName string
 	Err      error
+	// Annotated is true when the failure was already reported to the CI
+	// provider by the output style
+	Annotated bool
 }
 
 func (err *TaskRunError) Error() string {
@@ -206,3 +209,50 @@ func (err *TaskNotAllowedVarsError) Error() string {
 func (err *TaskNotAllowedVarsError) Code() int {
 	return CodeTaskNotAllowedVars
 }
+
+// TaskUndefinedVarError is returned in strict mode when a template uses a
+// variable that is not defined.
+type TaskUndefinedVarError struct {
+	TaskName string
+	Location string
+	Var      string
+	Template string
+}
+
+func (err *TaskUndefinedVarError) Error() string {
+	msg := fmt.Sprintf("task: Variable %q is not defined in template %q", err.Var, err.Template)
+	if err.TaskName != "" {
+		msg += fmt.Sprintf(" of task %q", err.TaskName)
+	}
+	if err.Location != "" {
+		msg += fmt.Sprintf(" (%s)", err.Location)
+	}
+	return msg
+}
+
+func (err *TaskUndefinedVarError) Code() int {
+	return CodeTaskUndefinedVar
+}
+
+// TaskProfileRequiredError is returned when a task requires a profile and it
+// is called without one of the allowed profiles.
+type TaskProfileRequiredError struct {
+	TaskName        string
+	Profile         string
+	AllowedProfiles []string
+}
+
+func (err *TaskProfileRequiredError) Error() string {
+	if err.Profile == "" {
+		msg := fmt.Sprintf("task: Task %q cancelled because it requires a profile", err.TaskName)
+		if len(err.AllowedProfiles) > 0 {
+			msg += fmt.Sprintf(" (allowed profiles : %v)", err.AllowedProfiles)
+		}
+		return msg
+	}
+	return fmt.Sprintf("task: Task %q cancelled because it can't run with profile %q (allowed profiles : %v)", err.TaskName, err.Profile, err.AllowedProfiles)
+}
+
+func (err *TaskProfileRequiredError) Code() int {
+	return CodeTaskProfileRequired
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -224,3 +225,110 @@ func (err *TaskfileDoesNotMatchChecksum) Error() string {
 func (err *TaskfileDoesNotMatchChecksum) Code() int {
 	return CodeTaskfileDoesNotMatchChecksum
 }
+
+// TaskfileNotLockedError is returned when a remote Taskfile is included but
+// is missing from the lockfile of the project.
+type TaskfileNotLockedError struct {
+	URI string
+}
+
+func (err *TaskfileNotLockedError) Error() string {
+	return fmt.Sprintf(
+		"task: The remote Taskfile at %q is not in the lockfile. Run `task --lock` to update it",
+		err.URI,
+	)
+}
+
+func (err *TaskfileNotLockedError) Code() int {
+	return CodeTaskfileNotLocked
+}
+
+// TaskfileSignatureError is returned when the signature of a remote Taskfile
+// is missing or could not be verified with any of the trusted keys.
+type TaskfileSignatureError struct {
+	URI string
+	Err error
+}
+
+func (err *TaskfileSignatureError) Error() string {
+	return fmt.Sprintf(
+		"task: The signature of the Taskfile at %q could not be verified: %v",
+		err.URI,
+		err.Err,
+	)
+}
+
+func (err *TaskfileSignatureError) Unwrap() error {
+	return err.Err
+}
+
+func (err *TaskfileSignatureError) Code() int {
+	return CodeTaskfileSignatureInvalid
+}
+
+// IncludeMissingRequiredVarsError is returned when an include does not pass
+// the variables required by the included Taskfile.
+type IncludeMissingRequiredVarsError struct {
+	URI         string
+	Namespace   string
+	MissingVars []MissingVar
+}
+
+func (err *IncludeMissingRequiredVarsError) Error() string {
+	vars := make([]string, 0, len(err.MissingVars))
+	for _, v := range err.MissingVars {
+		vars = append(vars, v.String())
+	}
+
+	return fmt.Sprintf(
+		`task: Taskfile %q included as %q is missing required variables: %s`,
+		err.URI,
+		err.Namespace,
+		strings.Join(vars, ", "),
+	)
+}
+
+func (err *IncludeMissingRequiredVarsError) Code() int {
+	return CodeTaskfileMissingRequiredVars
+}
+
+// IncludeNotAllowedVarsError is returned when an include passes a value to the
+// included Taskfile that is not allowed.
+type IncludeNotAllowedVarsError struct {
+	URI            string
+	Namespace      string
+	NotAllowedVars []NotAllowedVar
+}
+
+func (err *IncludeNotAllowedVarsError) Error() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("task: Taskfile %q included as %q has variables with invalid values:\n", err.URI, err.Namespace))
+	for _, s := range err.NotAllowedVars {
+		builder.WriteString(fmt.Sprintf("  - %s has an invalid value : '%s' (allowed values : %v)\n", s.Name, s.Value, s.Enum))
+	}
+
+	return builder.String()
+}
+
+func (err *IncludeNotAllowedVarsError) Code() int {
+	return CodeTaskfileNotAllowedVars
+}
+
+// TaskfileProfileNotFoundError is returned when the selected profile is not
+// declared in the Taskfile.
+type TaskfileProfileNotFoundError struct {
+	Profile  string
+	Profiles []string
+}
+
+func (err *TaskfileProfileNotFoundError) Error() string {
+	if len(err.Profiles) == 0 {
+		return fmt.Sprintf(`task: Profile %q does not exist, the Taskfile has no profiles`, err.Profile)
+	}
+	return fmt.Sprintf(`task: Profile %q does not exist (available profiles : %v)`, err.Profile, err.Profiles)
+}
+
+func (err *TaskfileProfileNotFoundError) Code() int {
+	return CodeTaskfileProfileNotFound
+}
@@ -10,9 +10,11 @@ import (
 	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/sajari/fuzzy"
 
+	"github.com/vikbert/taskr/v3/internal/execext"
 	"github.com/vikbert/taskr/v3/internal/logger"
 	"github.com/vikbert/taskr/v3/internal/output"
 	"github.com/vikbert/taskr/v3/internal/sort"
+	"github.com/vikbert/taskr/v3/taskfile"
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 )
 
@@ -34,7 +36,10 @@ type (
 		Insecure            bool
 		Download            bool
 		Offline             bool
+		Lock                bool
 		TrustedHosts        []string
+		TrustedKeys         []string
+		RemoteAuth          map[string]*taskfile.Auth
 		Timeout             time.Duration
 		CacheExpiryDuration time.Duration
 		RemoteCacheDir      string
@@ -46,11 +51,22 @@ type (
 		AssumeTerm          bool // Used for testing
 		Dry                 bool
 		Summary             bool
+		ExplainVars         bool
 		Parallel            bool
 		Color               bool
 		Concurrency         int
 		Interval            time.Duration
 		Failfast            bool
+		ShutdownSequence    []ShutdownStep
+		DeferTimeout        time.Duration
+		ContainerRuntime    string
+		Hosts               map[string]Host
+		TargetHosts         []string
+		Strict              bool
+		Environment         string
+		Profile             string
+		SecretProviders     map[string]SecretProvider
+		LogDir              string
 
 		// I/O
 		Stdin  io.Reader
@@ -66,20 +82,30 @@ type (
 		TaskSorter         sort.Sorter
 		UserWorkingDir     string
 		EnableVersionCheck bool
+		EnableCIOutput     bool
 
 		fuzzyModel     *fuzzy.Model
 		fuzzyModelOnce sync.Once
 
 		concurrencySemaphore chan struct{}
+		resourceSemaphores   map[string]chan struct{}
 		taskCallCount        map[string]*int32
 		mkdirMutexMap        map[string]*sync.Mutex
 		executionHashes      map[string]context.Context
 		executionHashesMutex sync.Mutex
 		watchedDirs          *xsync.Map[string, bool]
+		processes            *execext.ProcessRegistry
+		deferredProcesses    *execext.ProcessRegistry
+		shutdownState        shutdownState
+		taskfileLocations    []string
+		downloadNamespaces   []string
 	}
 	TempDir struct {
 		Remote      string
 		Fingerprint string
+		// Taskfile is the directory where the parsed Taskfiles are cached.
+		// Nothing is cached when it is empty.
+		Taskfile string
 	}
 )
 
@@ -88,6 +114,7 @@ type (
 func NewExecutor(opts ...ExecutorOption) *Executor {
 	e := &Executor{
 		Timeout:              time.Second * 10,
+		DeferTimeout:         time.Second * 30,
 		Stdin:                os.Stdin,
 		Stdout:               os.Stdout,
 		Stderr:               os.Stderr,
@@ -103,6 +130,8 @@ func NewExecutor(opts ...ExecutorOption) *Executor {
 		mkdirMutexMap:        map[string]*sync.Mutex{},
 		executionHashes:      map[string]context.Context{},
 		executionHashesMutex: sync.Mutex{},
+		processes:            execext.NewProcessRegistry(),
+		deferredProcesses:    execext.NewProcessRegistry(),
 	}
 	e.Options(opts...)
 	return e
@@ -230,6 +259,20 @@ func (o *offlineOption) ApplyToExecutor(e *Executor) {
 	e.Offline = o.offline
 }
 
+// WithLock makes the [Executor] resolve every remote Taskfile again and record
+// them in the lockfile instead of enforcing it.
+func WithLock(lock bool) ExecutorOption {
+	return &lockOption{lock}
+}
+
+type lockOption struct {
+	lock bool
+}
+
+func (o *lockOption) ApplyToExecutor(e *Executor) {
+	e.Lock = o.lock
+}
+
 // WithTrustedHosts configures the [Executor] with a list of trusted hosts for remote
 // Taskfiles. Hosts in this list will not prompt for user confirmation.
 func WithTrustedHosts(trustedHosts []string) ExecutorOption {
@@ -244,6 +287,34 @@ func (o *trustedHostsOption) ApplyToExecutor(e *Executor) {
 	e.TrustedHosts = o.trustedHosts
 }
 
+// WithTrustedKeys configures the [Executor] with a list of minisign public
+// keys. If any are given, every remote Taskfile must be signed with one of them.
+func WithTrustedKeys(trustedKeys []string) ExecutorOption {
+	return &trustedKeysOption{trustedKeys}
+}
+
+type trustedKeysOption struct {
+	trustedKeys []string
+}
+
+func (o *trustedKeysOption) ApplyToExecutor(e *Executor) {
+	e.TrustedKeys = o.trustedKeys
+}
+
+// WithRemoteAuth configures the [Executor] with the auth used to download
+// remote Taskfiles, keyed by host.
+func WithRemoteAuth(auth map[string]*taskfile.Auth) ExecutorOption {
+	return &remoteAuthOption{auth}
+}
+
+type remoteAuthOption struct {
+	auth map[string]*taskfile.Auth
+}
+
+func (o *remoteAuthOption) ApplyToExecutor(e *Executor) {
+	e.RemoteAuth = o.auth
+}
+
 // WithTimeout sets the [Executor]'s timeout for fetching remote taskfiles. By
 // default, the timeout is set to 10 seconds.
 func WithTimeout(timeout time.Duration) ExecutorOption {
@@ -395,6 +466,20 @@ func (o *summaryOption) ApplyToExecutor(e *Executor) {
 	e.Summary = o.summary
 }
 
+// WithExplainVars tells the [Executor] to output the variables of the given
+// tasks and where their values come from instead of running them.
+func WithExplainVars(explainVars bool) ExecutorOption {
+	return &explainVarsOption{explainVars}
+}
+
+type explainVarsOption struct {
+	explainVars bool
+}
+
+func (o *explainVarsOption) ApplyToExecutor(e *Executor) {
+	e.ExplainVars = o.explainVars
+}
+
 // WithParallel tells the [Executor] to run tasks given in the same call in
 // parallel.
 func WithParallel(parallel bool) ExecutorOption {
@@ -560,3 +645,169 @@ type failfastOption struct {
 func (o *failfastOption) ApplyToExecutor(e *Executor) {
 	e.Failfast = o.failfast
 }
+
+// WithShutdownSequence sets the sequence of signals that the [Executor] sends to
+// running commands after receiving an interrupt signal. By default, the
+// received signal is forwarded to the running commands.
+func WithShutdownSequence(sequence []ShutdownStep) ExecutorOption {
+	return &shutdownSequenceOption{sequence}
+}
+
+type shutdownSequenceOption struct {
+	sequence []ShutdownStep
+}
+
+func (o *shutdownSequenceOption) ApplyToExecutor(e *Executor) {
+	e.ShutdownSequence = o.sequence
+}
+
+// WithDeferTimeout sets the maximum time that deferred commands are allowed to
+// run for once the [Executor] has received an interrupt signal. By default,
+// the timeout is set to 30 seconds. A zero duration means that deferred
+// commands are not given a deadline.
+func WithDeferTimeout(timeout time.Duration) ExecutorOption {
+	return &deferTimeoutOption{timeout}
+}
+
+type deferTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (o *deferTimeoutOption) ApplyToExecutor(e *Executor) {
+	e.DeferTimeout = o.timeout
+}
+
+// WithContainerRuntime sets the container CLI (e.g. "docker" or "podman") that
+// the [Executor] uses to run the commands of tasks that declare a container.
+// By default, "docker" is used.
+func WithContainerRuntime(runtime string) ExecutorOption {
+	return &containerRuntimeOption{runtime}
+}
+
+type containerRuntimeOption struct {
+	runtime string
+}
+
+func (o *containerRuntimeOption) ApplyToExecutor(e *Executor) {
+	e.ContainerRuntime = o.runtime
+}
+
+// WithHosts sets the inventory of hosts on which the [Executor] can run
+// commands over SSH.
+func WithHosts(hosts map[string]Host) ExecutorOption {
+	return &hostsOption{hosts}
+}
+
+type hostsOption struct {
+	hosts map[string]Host
+}
+
+func (o *hostsOption) ApplyToExecutor(e *Executor) {
+	e.Hosts = o.hosts
+}
+
+// WithTargetHosts sets the names of the hosts on which the [Executor] runs the
+// commands of every task, regardless of the hosts declared by the tasks.
+func WithTargetHosts(hosts []string) ExecutorOption {
+	return &targetHostsOption{hosts}
+}
+
+type targetHostsOption struct {
+	hosts []string
+}
+
+func (o *targetHostsOption) ApplyToExecutor(e *Executor) {
+	e.TargetHosts = o.hosts
+}
+
+// WithStrict tells the [Executor] to fail when the templates of a task use
+// undefined variables instead of rendering them as empty strings. Tasks of
+// Taskfiles with "strict: true" are always compiled strictly.
+func WithStrict(strict bool) ExecutorOption {
+	return &strictOption{strict}
+}
+
+type strictOption struct {
+	strict bool
+}
+
+func (o *strictOption) ApplyToExecutor(e *Executor) {
+	e.Strict = o.strict
+}
+
+// WithEnvironment sets the name of the environment whose dotenv files are
+// layered on top of the dotenv files of the Taskfiles. For example, the
+// "production" environment reads ".env.production" and ".env.production.local"
+// in addition to ".env".
+func WithEnvironment(environment string) ExecutorOption {
+	return &environmentOption{environment}
+}
+
+type environmentOption struct {
+	environment string
+}
+
+func (o *environmentOption) ApplyToExecutor(e *Executor) {
+	e.Environment = o.environment
+}
+
+// WithProfile sets the name of the profile of the Taskfile whose variables and
+// environment variables override those of the Taskfile.
+func WithProfile(profile string) ExecutorOption {
+	return &profileOption{profile}
+}
+
+type profileOption struct {
+	profile string
+}
+
+func (o *profileOption) ApplyToExecutor(e *Executor) {
+	e.Profile = o.profile
+}
+
+// WithSecretProvider registers a provider that resolves the secret variables
+// that declare the given name as their provider. It replaces the built-in
+// provider with the same name, if any.
+func WithSecretProvider(name string, provider SecretProvider) ExecutorOption {
+	return &secretProviderOption{name, provider}
+}
+
+type secretProviderOption struct {
+	name     string
+	provider SecretProvider
+}
+
+func (o *secretProviderOption) ApplyToExecutor(e *Executor) {
+	if e.SecretProviders == nil {
+		e.SecretProviders = map[string]SecretProvider{}
+	}
+	e.SecretProviders[o.name] = o.provider
+}
+
+// WithCIOutput tells the [Executor] whether or not to use the output style of
+// the CI provider it is running on when no output style is set.
+func WithCIOutput(enableCIOutput bool) ExecutorOption {
+	return &ciOutputOption{enableCIOutput}
+}
+
+type ciOutputOption struct {
+	enableCIOutput bool
+}
+
+func (o *ciOutputOption) ApplyToExecutor(e *Executor) {
+	e.EnableCIOutput = o.enableCIOutput
+}
+
+// WithLogDir sets the directory that the output of every task is saved to, in
+// one file per task invocation. Tasks can opt out with "log: false".
+func WithLogDir(dir string) ExecutorOption {
+	return &logDirOption{dir}
+}
+
+type logDirOption struct {
+	dir string
+}
+
+func (o *logDirOption) ApplyToExecutor(e *Executor) {
+	e.LogDir = o.dir
+}
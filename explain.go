@@ -0,0 +1,153 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/vikbert/taskr/v3/internal/filepathext"
+	"github.com/vikbert/taskr/v3/internal/logger"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// The layers that variables are resolved from, in the order they are applied
+const (
+	VarLayerEnvironment          = "environment"
+	VarLayerSpecial              = "special"
+	VarLayerDotenv               = "dotenv"
+	VarLayerTaskfileEnv          = "taskfile env"
+	VarLayerCLI                  = "command line"
+	VarLayerTaskfileVars         = "taskfile vars"
+	VarLayerIncludeVars          = "include vars"
+	VarLayerIncludedTaskfileVars = "included taskfile vars"
+	VarLayerProfile              = "profile vars"
+	VarLayerCall                 = "call vars"
+	VarLayerTask                 = "task vars"
+)
+
+// VarOrigin is a value that a variable took in one of the layers
+type VarOrigin struct {
+	Layer string
+	// Location is where the variable is declared, if it is declared in a
+	// Taskfile or a dotenv file
+	Location *ast.Location
+	// Sh is the command of dynamic variables
+	Sh  *string
+	Ref string
+	// Secret is the provider of secret variables
+	Secret *ast.Secret
+	Value  any
+}
+
+// VarOrigins maps the name of each variable to the values it took, in the order
+// they were applied
+type VarOrigins map[string][]VarOrigin
+
+func (origins VarOrigins) record(name, layer string, v ast.Var, value any) {
+	if origins == nil {
+		return
+	}
+	if v.Shadowed != nil {
+		origins.record(name, layer, *v.Shadowed, v.Shadowed.Value)
+	}
+	origins[name] = append(origins[name], VarOrigin{
+		Layer:    varLayerOf(layer, v),
+		Location: v.Location,
+		Sh:       v.Sh,
+		Ref:      v.Ref,
+		Secret:   v.Secret,
+		Value:    value,
+	})
+}
+
+// varLayerOf tells apart the variables that are merged into the variables and
+// the environment of the Taskfile: dotenv files have no line and variables
+// passed on the command line have no location.
+func varLayerOf(layer string, v ast.Var) string {
+	switch layer {
+	case VarLayerTaskfileEnv:
+		if v.Location != nil && v.Location.Line == 0 {
+			return VarLayerDotenv
+		}
+	case VarLayerTaskfileVars:
+		if v.Location == nil {
+			return VarLayerCLI
+		}
+	}
+	return layer
+}
+
+// ExplainTaskVars prints the final variables of the given tasks with the layer,
+// file and line they come from, followed by the values they shadow. Variables
+// that only come from the environment are omitted.
+func (e *Executor) ExplainTaskVars(calls ...*Call) error {
+	for i, call := range calls {
+		t, err := e.GetTask(call)
+		if err != nil {
+			return err
+		}
+		vars, origins, err := e.Compiler.ExplainVariables(t, call)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			e.Logger.Outf(logger.Default, "\n")
+		}
+		e.Logger.Outf(logger.Default, "task: ")
+		e.Logger.Outf(logger.Green, "%s\n", t.Name())
+
+		for name, v := range vars.All() {
+			history := origins[name]
+			if len(history) == 0 || onlyFromEnvironment(history) {
+				continue
+			}
+			final := history[len(history)-1]
+			e.Logger.Outf(logger.Default, "\n")
+			e.Logger.Outf(logger.Yellow, "%s", name)
+			e.Logger.Outf(logger.Default, ": %s\n", e.Compiler.Redact(formatOriginValue(v.Value)))
+			e.Logger.Outf(logger.Default, "  from %s\n", e.Compiler.Redact(describeOrigin(final)))
+			for j := len(history) - 2; j >= 0; j-- {
+				shadowed := history[j]
+				e.Logger.Outf(logger.Default, "  shadows %s from %s\n", e.Compiler.Redact(formatOriginValue(shadowed.Value)), e.Compiler.Redact(describeOrigin(shadowed)))
+			}
+		}
+	}
+	return nil
+}
+
+func onlyFromEnvironment(history []VarOrigin) bool {
+	for _, origin := range history {
+		if origin.Layer != VarLayerEnvironment {
+			return false
+		}
+	}
+	return true
+}
+
+func describeOrigin(origin VarOrigin) string {
+	s := origin.Layer
+	if origin.Location != nil && origin.Location.Taskfile != "" {
+		location := filepathext.TryAbsToRel(origin.Location.Taskfile)
+		if origin.Location.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", location, origin.Location.Line, origin.Location.Column)
+		}
+		s += fmt.Sprintf(" (%s)", location)
+	}
+	if origin.Sh != nil {
+		s += fmt.Sprintf(", sh: %s", *origin.Sh)
+	}
+	if origin.Ref != "" {
+		s += fmt.Sprintf(", ref: %s", origin.Ref)
+	}
+	if origin.Secret != nil {
+		s += fmt.Sprintf(", secret: %s", origin.Secret.Provider)
+	}
+	return s
+}
+
+func formatOriginValue(value any) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,125 @@
+package task
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/vikbert/taskr/v3/internal/env"
+	"github.com/vikbert/taskr/v3/internal/execext"
+	"github.com/vikbert/taskr/v3/internal/logger"
+	"github.com/vikbert/taskr/v3/internal/output"
+	"github.com/vikbert/taskr/v3/internal/templater"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// A Host is a remote machine of the host inventory on which commands can be
+// run over SSH.
+type Host struct {
+	Address string
+	Port    int
+	User    string
+	// IdentityFile is the private key used to authenticate. If empty, the
+	// keys of the running SSH agent and the default keys in ~/.ssh are used.
+	IdentityFile string
+	// KnownHosts is the known_hosts file used to verify the host key.
+	// Defaults to ~/.ssh/known_hosts.
+	KnownHosts string
+	// Insecure disables the verification of the host key.
+	Insecure bool
+	// Dir is the directory in which commands are run on the host.
+	Dir string
+}
+
+// hostsFor returns the names of the hosts on which the given command of the
+// task is run. Hosts given on the command line take precedence over the ones
+// declared by the task, but commands that can't run over SSH are rejected
+// rather than retargeted.
+func (e *Executor) hostsFor(t *ast.Task, cmd *ast.Cmd) ([]string, error) {
+	if len(e.TargetHosts) == 0 {
+		return t.Hosts, nil
+	}
+	switch {
+	case t.Container != nil:
+		return nil, fmt.Errorf(`task: Task %q runs in a container and cannot run on the hosts given with --host`, t.Name())
+	case cmp.Or(cmd.Interpreter, t.Interpreter) != "":
+		return nil, fmt.Errorf(`task: Task %q uses an interpreter and cannot run on the hosts given with --host`, t.Name())
+	}
+	return e.TargetHosts, nil
+}
+
+// runCommandOnHosts runs the command of the given options on every given host
+// in parallel. The output of each host is written through the output style of
+// the task, prefixed with the name of the host.
+func (e *Executor) runCommandOnHosts(
+	ctx context.Context,
+	t *ast.Task,
+	opts *execext.RunCommandOptions,
+	names []string,
+	outputWrapper output.Output,
+	cache *templater.Cache,
+	section *taskSection,
+	log *taskLog,
+) error {
+	hosts := make([]Host, len(names))
+	for i, name := range names {
+		host, ok := e.Hosts[name]
+		if !ok {
+			return fmt.Errorf("task: Host %q is not defined in the host inventory", name)
+		}
+		hosts[i] = host
+	}
+
+	// Interleaved output would make it impossible to tell hosts apart, and
+	// so would the sections of the CI providers that stream it
+	_, interleaved := outputWrapper.(output.Interleaved)
+	if _, ok := output.AsSectioner(outputWrapper); ok {
+		interleaved = true
+	}
+	if interleaved && len(names) > 1 && !t.Interactive {
+		outputWrapper = output.NewPrefixed(e.Logger)
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	if e.Concurrency > 0 {
+		g.SetLimit(e.Concurrency)
+	}
+	for i, name := range names {
+		host := hosts[i]
+		g.Go(func() error {
+			stdOut, stdErr := section.writers(e.Stdout, e.Stderr)
+			stdOut, stdErr, closer := outputWrapper.WrapWriter(stdOut, stdErr, fmt.Sprintf("%s@%s", t.Prefix, name), cache)
+			stdOut, stdErr = log.tee(stdOut, stdErr)
+			hostOpts := *opts
+			hostOpts.PosixOpts = slices.Clone(opts.PosixOpts)
+			hostOpts.Stdout = stdOut
+			hostOpts.Stderr = stdErr
+			// The session keeps copying stdin after the command finished, so
+			// it is only attached to interactive tasks run on a single host
+			hostOpts.Stdin = nil
+			if t.Interactive && len(names) == 1 {
+				hostOpts.Stdin = opts.Stdin
+			}
+			hostOpts.SSH = &execext.SSHOptions{
+				Name:         name,
+				Address:      host.Address,
+				Port:         host.Port,
+				User:         host.User,
+				IdentityFile: host.IdentityFile,
+				KnownHosts:   host.KnownHosts,
+				Insecure:     host.Insecure,
+				Dir:          host.Dir,
+				Env:          env.GetDeclared(t),
+			}
+			err := execext.RunCommand(ctx, &hostOpts)
+			if closeErr := closer(err); closeErr != nil {
+				e.Logger.Errf(logger.Red, "task: unable to close writer: %v\n", closeErr)
+			}
+			return err
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,208 @@
+package task_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+
+	task "github.com/vikbert/taskr/v3"
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+func TestHosts(t *testing.T) {
+	t.Parallel()
+
+	hosts := startSSHServer(t)
+
+	tests := []struct {
+		call        string
+		targetHosts []string
+		expected    []string
+		exitCode    int
+		expectedErr string
+	}{
+		{call: "single", expected: []string{"hello from single on a\n"}},
+		{call: "fanout", expected: []string{"[fanout@a] hi from a\n", "[fanout@b] hi from b\n"}},
+		{call: "single", targetHosts: []string{"b"}, expected: []string{"hello from single on b\n"}},
+		{call: "fail", exitCode: 2},
+		{call: "unknown", exitCode: errors.CodeTaskRunError},
+		// The sessions of the hosts don't consume the input of the commands
+		// that follow
+		{call: "stdin", expected: []string{"[fanout@a] hi from a\n", "[fanout@b] hi from b\n", "read input\n"}},
+		{call: "container", targetHosts: []string{"a"}, expectedErr: `task: Task "container" runs in a container and cannot run on the hosts given with --host`},
+		{call: "interpreter", targetHosts: []string{"a"}, expectedErr: `task: Task "interpreter" uses an interpreter and cannot run on the hosts given with --host`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.call, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir("testdata/hosts"),
+				// Hide the WriterTo of the reader so that stdin is consumed
+				// as soon as it is read, like a terminal
+				task.WithStdin(struct{ io.Reader }{strings.NewReader("input\n")}),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+				task.WithHosts(hosts),
+				task.WithTargetHosts(test.targetHosts),
+			)
+			require.NoError(t, e.Setup())
+			err := e.Run(t.Context(), &task.Call{Task: test.call})
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+			if test.exitCode != 0 {
+				var runErr *errors.TaskRunError
+				require.ErrorAs(t, err, &runErr)
+				assert.Equal(t, test.exitCode, runErr.TaskExitCode())
+				return
+			}
+			require.NoError(t, err)
+			for _, expected := range test.expected {
+				assert.Contains(t, buff.String(), expected)
+			}
+		})
+	}
+}
+
+// startSSHServer starts an in-process SSH server that runs the commands it
+// receives with the embedded shell interpreter and returns a host inventory
+// pointing to it. The hosts log in with their own name as user, which the
+// commands can read from $SSH_USER to tell them apart.
+func startSSHServer(t *testing.T) map[string]task.Host {
+	t.Helper()
+
+	dir := t.TempDir()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	clientSigner, err := ssh.NewSignerFromKey(clientKey)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !bytes.Equal(key.Marshal(), clientSigner.PublicKey().Marshal()) {
+				return nil, errors.New("unknown public key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, config)
+		}
+	}()
+
+	identityFile := filepath.Join(dir, "id_ed25519")
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(identityFile, pem.EncodeToMemory(block), 0o600))
+
+	addr := listener.Addr().(*net.TCPAddr)
+	knownHosts := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr.String())}, hostSigner.PublicKey())
+	require.NoError(t, os.WriteFile(knownHosts, []byte(line+"\n"), 0o600))
+
+	hosts := map[string]task.Host{}
+	for _, name := range []string{"a", "b"} {
+		hosts[name] = task.Host{
+			Address:      addr.IP.String(),
+			Port:         addr.Port,
+			User:         name,
+			IdentityFile: identityFile,
+			KnownHosts:   knownHosts,
+		}
+	}
+	return hosts
+}
+
+func serveSSH(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				var payload struct{ Command string }
+				_ = ssh.Unmarshal(req.Payload, &payload)
+
+				var status uint32
+				if err := runShell(payload.Command, serverConn.User(), channel, channel.Stderr()); err != nil {
+					var exitStatus interp.ExitStatus
+					if errors.As(err, &exitStatus) {
+						status = uint32(exitStatus)
+					} else {
+						status = 1
+					}
+				}
+				_, _ = channel.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+				return
+			}
+		}()
+	}
+}
+
+func runShell(command, user string, stdout, stderr io.Writer) error {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return err
+	}
+	runner, err := interp.New(
+		interp.StdIO(nil, stdout, stderr),
+		interp.Env(expand.ListEnviron("SSH_USER="+user)),
+	)
+	if err != nil {
+		return err
+	}
+	return runner.Run(context.Background(), file)
+}
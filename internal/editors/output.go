@@ -19,6 +19,7 @@ type (
 		Summary  string    `json:"summary"`
 		Category string    `json:"category,omitempty"`
 		Aliases  []string  `json:"aliases"`
+		Uses     []string  `json:"uses,omitempty"`
 		UpToDate *bool     `json:"up_to_date,omitempty"`
 		Location *Location `json:"location"`
 	}
@@ -42,6 +43,7 @@ func NewTask(task *ast.Task) Task {
 		Summary:  task.Summary,
 		Category: task.Category,
 		Aliases:  aliases,
+		Uses:     task.Uses,
 		Location: &Location{
 			Line:     task.Location.Line,
 			Column:   task.Location.Column,
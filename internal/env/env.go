@@ -3,6 +3,7 @@ package env
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/vikbert/taskr/v3/experiments"
@@ -49,6 +50,38 @@ func GetFromVars(env *ast.Vars) []string {
 	return environ
 }
 
+// GetDeclared returns the environment variables declared by the Taskfile and
+// the given task. Unlike [Get], the host environment is not included.
+func GetDeclared(t *ast.Task) []string {
+	return getDeclared(t.Env)
+}
+
+// GetContainer is like [GetDeclared], but also includes the variables declared
+// by the container of the given task.
+func GetContainer(t *ast.Task) []string {
+	if t.Container == nil {
+		return getDeclared(t.Env)
+	}
+	return getDeclared(t.Env, t.Container.Env)
+}
+
+func getDeclared(envs ...*ast.Vars) []string {
+	vars := ast.NewVars()
+	for _, env := range envs {
+		vars.Merge(env, nil)
+	}
+
+	var environ []string
+	for k, v := range vars.ToCacheMap() {
+		if !isTypeAllowed(v) {
+			continue
+		}
+		environ = append(environ, fmt.Sprintf("%s=%v", k, v))
+	}
+	slices.Sort(environ)
+	return environ
+}
+
 func isTypeAllowed(v any) bool {
 	switch v.(type) {
 	case string, bool, int, float32, float64:
@@ -0,0 +1,113 @@
+package execext
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// DefaultContainerRuntime is the container CLI used when none is configured.
+const DefaultContainerRuntime = "docker"
+
+// ContainerOptions describes the container in which a command is run instead
+// of the embedded shell interpreter.
+type ContainerOptions struct {
+	// Runtime is the container CLI to use (e.g. "docker" or "podman").
+	Runtime string
+	Image   string
+	// Volumes are extra volumes to mount, in the "src:dst[:opts]" format of
+	// the container CLI. Relative sources are resolved against the command's
+	// directory.
+	Volumes []string
+	// Workdir is the path in the container at which the command's directory
+	// is mounted. If empty, the directory is mounted at the same path it has
+	// on the host.
+	Workdir string
+	// Env is the list of "KEY=value" variables set in the container. The
+	// host environment is not passed through.
+	Env []string
+}
+
+// runContainer runs the command of the given options inside a container.
+func runContainer(ctx context.Context, opts *RunCommandOptions) error {
+	if len(opts.BashOpts) > 0 {
+		return errors.New("execext: shopt is not supported for commands run in a container")
+	}
+
+	args, err := containerArgs(opts)
+	if err != nil {
+		return err
+	}
+
+	runtimeName := opts.Container.Runtime
+	if runtimeName == "" {
+		runtimeName = DefaultContainerRuntime
+	}
+	path, err := exec.LookPath(runtimeName)
+	if err != nil {
+		return fmt.Errorf("execext: container runtime %q not found: %w", runtimeName, err)
+	}
+
+	cmd := &exec.Cmd{
+		Path:   path,
+		Args:   append([]string{runtimeName}, args...),
+		Dir:    opts.Dir,
+		Stdin:  opts.Stdin,
+		Stdout: opts.Stdout,
+		Stderr: opts.Stderr,
+	}
+	return runProcess(ctx, cmd, opts.Processes)
+}
+
+// containerArgs returns the arguments passed to the container CLI to run the
+// command of the given options.
+func containerArgs(opts *RunCommandOptions) ([]string, error) {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	workdir := opts.Container.Workdir
+	if workdir == "" {
+		workdir = filepath.ToSlash(dir)
+	}
+
+	args := []string{"run", "--rm", "-i"}
+	args = append(args, "-v", dir+":"+workdir, "-w", workdir)
+	for _, volume := range opts.Container.Volumes {
+		src, rest, ok := strings.Cut(volume, ":")
+		if ok && (strings.HasPrefix(src, ".") || strings.HasPrefix(src, "~")) {
+			if src, err = ExpandLiteral(src); err != nil {
+				return nil, err
+			}
+			if !filepath.IsAbs(src) {
+				src = filepath.Join(dir, src)
+			}
+			volume = src + ":" + rest
+		}
+		args = append(args, "-v", volume)
+	}
+	for _, kv := range opts.Container.Env {
+		args = append(args, "-e", kv)
+	}
+	args = append(args, opts.Container.Image)
+
+	// Apply the same POSIX options the embedded interpreter would use
+	var set []string
+	for _, opt := range opts.PosixOpts {
+		if len(opt) == 1 {
+			set = append(set, "-"+opt)
+		} else {
+			set = append(set, "-o", opt)
+		}
+	}
+	script := opts.Command
+	if len(set) > 0 {
+		script = fmt.Sprintf("set %s\n%s", strings.Join(set, " "), script)
+	}
+	args = append(args, "sh", "-c", script)
+	return args, nil
+}
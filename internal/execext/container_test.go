@@ -0,0 +1,98 @@
+package execext
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerArgs(t *testing.T) {
+	t.Parallel()
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	args, err := containerArgs(&RunCommandOptions{
+		Command:   "go test ./...",
+		Dir:       dir,
+		PosixOpts: []string{"e", "pipefail"},
+		Container: &ContainerOptions{
+			Image: "golang:1.24",
+			Volumes: []string{
+				"~/.cache/go-build:/root/.cache/go-build",
+				"./testdata:/testdata:ro",
+				"cache:/cache",
+			},
+			Env: []string{"CGO_ENABLED=0"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"run", "--rm", "-i",
+		"-v", dir + ":" + filepath.ToSlash(dir), "-w", filepath.ToSlash(dir),
+		"-v", filepath.Join(home, ".cache/go-build") + ":/root/.cache/go-build",
+		"-v", filepath.Join(dir, "testdata") + ":/testdata:ro",
+		"-v", "cache:/cache",
+		"-e", "CGO_ENABLED=0",
+		"golang:1.24",
+		"sh", "-c", "set -e -o pipefail\ngo test ./...",
+	}, args)
+}
+
+func TestContainerArgsWorkdir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	args, err := containerArgs(&RunCommandOptions{
+		Command:   "make",
+		Dir:       dir,
+		Container: &ContainerOptions{Image: "alpine", Workdir: "/src"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run", "--rm", "-i", "-v", dir + ":/src", "-w", "/src", "alpine", "sh", "-c", "make"}, args)
+}
+
+func TestRunContainer(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake container runtime is a shell script")
+	}
+
+	// The fake runtime prints the arguments it is called with
+	dir := t.TempDir()
+	fakeRuntime := filepath.Join(dir, "fake-runtime")
+	require.NoError(t, os.WriteFile(fakeRuntime, []byte("#!/bin/sh\necho \"$@\"\n"), 0o755))
+
+	var stdout bytes.Buffer
+	err := runContainer(t.Context(), &RunCommandOptions{
+		Command:   "echo hello",
+		Dir:       dir,
+		Stdout:    &stdout,
+		Stderr:    &stdout,
+		Container: &ContainerOptions{Runtime: fakeRuntime, Image: "alpine"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "run --rm -i -v "+dir+":"+dir+" -w "+dir+" alpine sh -c echo hello\n", stdout.String())
+
+	err = runContainer(t.Context(), &RunCommandOptions{
+		Command:   "echo hello",
+		Dir:       dir,
+		Container: &ContainerOptions{Runtime: filepath.Join(dir, "missing"), Image: "alpine"},
+	})
+	assert.ErrorContains(t, err, "not found")
+
+	err = runContainer(t.Context(), &RunCommandOptions{
+		Command:   "echo hello",
+		Dir:       dir,
+		BashOpts:  []string{"globstar"},
+		Container: &ContainerOptions{Image: "alpine"},
+	})
+	assert.ErrorContains(t, err, "shopt is not supported")
+}
@@ -29,6 +29,20 @@ type RunCommandOptions struct {
 	Stdin     io.Reader
 	Stdout    io.Writer
 	Stderr    io.Writer
+	// Processes, if set, keeps track of every process started by the command
+	// so that signals can be forwarded to them.
+	Processes *ProcessRegistry
+	// Container, if set, runs the command inside a container using the
+	// configured container CLI instead of the embedded interpreter.
+	Container *ContainerOptions
+	// Interpreter, if set, is the program used to run the command instead of
+	// the embedded interpreter. The command is written to a script in
+	// ScriptDir, whose path is passed as the last argument to the program.
+	Interpreter string
+	ScriptDir   string
+	// SSH, if set, runs the command on a remote host over SSH instead of
+	// using the embedded interpreter.
+	SSH *SSHOptions
 }
 
 // RunCommand runs a shell command
@@ -37,9 +51,20 @@ func RunCommand(ctx context.Context, opts *RunCommandOptions) error {
 		return ErrNilOptions
 	}
 
+	if opts.Interpreter != "" && opts.SSH == nil {
+		return runScript(ctx, opts)
+	}
+
 	// Set "-e" or "errexit" by default
 	opts.PosixOpts = append(opts.PosixOpts, "e")
 
+	if opts.SSH != nil {
+		return runSSH(ctx, opts)
+	}
+	if opts.Container != nil {
+		return runContainer(ctx, opts)
+	}
+
 	// Format POSIX options into a slice that mvdan/sh understands
 	var params []string
 	for _, opt := range opts.PosixOpts {
@@ -59,7 +84,7 @@ func RunCommand(ctx context.Context, opts *RunCommandOptions) error {
 	r, err := interp.New(
 		interp.Params(params...),
 		interp.Env(expand.ListEnviron(environ...)),
-		interp.ExecHandlers(execHandlers()...),
+		interp.ExecHandlers(execHandlers(opts)...),
 		interp.OpenHandler(openHandler),
 		interp.StdIO(opts.Stdin, opts.Stdout, opts.Stderr),
 		dirOption(opts.Dir),
@@ -143,10 +168,13 @@ func ExpandFields(s string) ([]string, error) {
 	return expand.Fields(cfg, words...)
 }
 
-func execHandlers() (handlers []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc) {
+func execHandlers(opts *RunCommandOptions) (handlers []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc) {
 	if useGoCoreUtils {
 		handlers = append(handlers, coreutils.ExecHandler)
 	}
+	if opts.Processes != nil {
+		handlers = append(handlers, registryExecHandler(opts.Processes, opts.Stdin))
+	}
 	return handlers
 }
 
@@ -0,0 +1,98 @@
+package execext
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/shell"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// scriptExtensions maps well-known interpreters to the file extension that
+// their scripts are expected to have. Some interpreters, such as PowerShell,
+// refuse to run scripts without it.
+var scriptExtensions = map[string]string{
+	"bash":       ".sh",
+	"sh":         ".sh",
+	"zsh":        ".sh",
+	"python":     ".py",
+	"python3":    ".py",
+	"node":       ".js",
+	"deno":       ".ts",
+	"ruby":       ".rb",
+	"perl":       ".pl",
+	"php":        ".php",
+	"pwsh":       ".ps1",
+	"powershell": ".ps1",
+}
+
+// runScript writes the command of the given options to a temporary script and
+// runs it with the configured interpreter.
+func runScript(ctx context.Context, opts *RunCommandOptions) error {
+	if opts.Container != nil {
+		return errors.New("execext: an interpreter cannot be used for commands run in a container")
+	}
+
+	args, err := shell.Fields(opts.Interpreter, nil)
+	if err != nil {
+		return fmt.Errorf("execext: invalid interpreter %q: %w", opts.Interpreter, err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("execext: invalid interpreter %q", opts.Interpreter)
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return fmt.Errorf("execext: interpreter %q not found: %w", args[0], err)
+	}
+
+	script, err := writeScript(opts.ScriptDir, args[0], opts.Command)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(script)
+
+	environ := opts.Env
+	if len(environ) == 0 {
+		environ = os.Environ()
+	}
+	cmd := &exec.Cmd{
+		Path:   path,
+		Args:   append(args, script),
+		Env:    environ,
+		Dir:    opts.Dir,
+		Stdin:  opts.Stdin,
+		Stdout: opts.Stdout,
+		Stderr: opts.Stderr,
+	}
+	return runProcess(ctx, cmd, opts.Processes)
+}
+
+// writeScript writes the given body to a new file in dir and returns its path.
+func writeScript(dir, interpreter, body string) (string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(interpreter), filepath.Ext(interpreter))
+	f, err := os.CreateTemp(dir, "script-*"+scriptExtensions[strings.ToLower(name)])
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(body); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,244 @@
+package execext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// killTimeout is the time given to a process to exit after being interrupted
+// because its context was cancelled before it is killed. This mirrors the
+// default used by [interp.DefaultExecHandler].
+const killTimeout = 2 * time.Second
+
+// A ProcessRegistry keeps track of the processes started by [RunCommand] so
+// that signals received by Task can be forwarded to them.
+type ProcessRegistry struct {
+	mu    sync.Mutex
+	procs map[*os.Process]bool
+}
+
+// NewProcessRegistry creates a new, empty [ProcessRegistry].
+func NewProcessRegistry() *ProcessRegistry {
+	return &ProcessRegistry{
+		procs: map[*os.Process]bool{},
+	}
+}
+
+// Len returns the number of processes that are currently running.
+func (r *ProcessRegistry) Len() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.procs)
+}
+
+// Signal sends the given signal to every running process. Processes that were
+// started in their own process group receive the signal on the whole group.
+// Processes sharing Task's process group are only signalled when
+// includeForeground is true, since a terminal will already have delivered the
+// signal to them. It returns the number of processes that were signalled.
+func (r *ProcessRegistry) Signal(sig os.Signal, includeForeground bool) int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for p, ownGroup := range r.procs {
+		if !ownGroup && !includeForeground {
+			continue
+		}
+		if err := signalProcess(p, sig, ownGroup); err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *ProcessRegistry) add(p *os.Process, ownGroup bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.procs[p] = ownGroup
+}
+
+func (r *ProcessRegistry) remove(p *os.Process) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.procs, p)
+}
+
+// registryExecHandler returns an exec handler that behaves like
+// [interp.DefaultExecHandler], but registers every started process in the
+// given [ProcessRegistry]. When stdin is not a terminal, processes are started
+// in their own process group so that Task is the only one deciding which
+// signals they receive.
+func registryExecHandler(registry *ProcessRegistry, stdin io.Reader) func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	ownGroup := runtime.GOOS != "windows" && !isTerminal(stdin)
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			hc := interp.HandlerCtx(ctx)
+			path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+			if err != nil {
+				fmt.Fprintln(hc.Stderr, err)
+				return interp.ExitStatus(127)
+			}
+			cmd := exec.Cmd{
+				Path:   path,
+				Args:   args,
+				Env:    execEnv(hc.Env),
+				Dir:    hc.Dir,
+				Stdin:  hc.Stdin,
+				Stdout: hc.Stdout,
+				Stderr: hc.Stderr,
+			}
+			if ownGroup {
+				setProcessGroup(&cmd)
+			}
+
+			err = cmd.Start()
+			if err == nil {
+				registry.add(cmd.Process, ownGroup)
+				stop := stopOnCancel(ctx, cmd.Process, ownGroup)
+				err = cmd.Wait()
+				stop()
+				registry.remove(cmd.Process)
+			}
+
+			switch err := err.(type) {
+			case *exec.ExitError:
+				if signal, ok := exitSignal(err); ok {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					return interp.ExitStatus(128 + signal)
+				}
+				return interp.ExitStatus(err.ExitCode())
+			case *exec.Error:
+				// did not start
+				fmt.Fprintf(hc.Stderr, "%v\n", err)
+				return interp.ExitStatus(127)
+			default:
+				return err
+			}
+		}
+	}
+}
+
+// runProcess runs the given command outside of the embedded interpreter and
+// registers it in the given [ProcessRegistry], if any. Its exit code is
+// returned as an [interp.ExitStatus], so it can be handled the same way as the
+// exit code of a shell command.
+func runProcess(ctx context.Context, cmd *exec.Cmd, registry *ProcessRegistry) error {
+	ownGroup := runtime.GOOS != "windows" && !isTerminal(cmd.Stdin)
+	if ownGroup {
+		setProcessGroup(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if registry != nil {
+		registry.add(cmd.Process, ownGroup)
+		defer registry.remove(cmd.Process)
+	}
+	stop := stopOnCancel(ctx, cmd.Process, ownGroup)
+	err := cmd.Wait()
+	stop()
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if signal, ok := exitSignal(exitErr); ok {
+			return interp.ExitStatus(128 + signal)
+		}
+		return interp.ExitStatus(exitErr.ExitCode())
+	}
+	return err
+}
+
+// stopOnCancel interrupts the given process once the context is cancelled,
+// and kills it if it is still running after [killTimeout]. The returned
+// function must be called once the process exited, so that its process group
+// is not killed afterwards.
+func stopOnCancel(ctx context.Context, p *os.Process, ownGroup bool) func() {
+	exited := make(chan struct{})
+	stopf := context.AfterFunc(ctx, func() {
+		if runtime.GOOS == "windows" {
+			_ = p.Kill()
+			return
+		}
+		_ = signalProcess(p, os.Interrupt, ownGroup)
+		select {
+		case <-time.After(killTimeout):
+			_ = signalProcess(p, os.Kill, ownGroup)
+		case <-exited:
+		}
+	})
+	return func() {
+		close(exited)
+		stopf()
+	}
+}
+
+// execEnv converts the runner's environment into a list of exported
+// variables, the same way [interp.DefaultExecHandler] does.
+func execEnv(env expand.Environ) []string {
+	list := make([]string, 0, 64)
+	for name, vr := range env.Each {
+		if !vr.IsSet() {
+			for i, kv := range list {
+				if strings.HasPrefix(kv, name+"=") {
+					list[i] = ""
+				}
+			}
+		}
+		if vr.Exported && vr.Kind == expand.String {
+			list = append(list, name+"="+vr.String())
+		}
+	}
+	return list
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// ParseSignal returns the signal with the given name. Names are case
+// insensitive and the "SIG" prefix is optional (e.g. "SIGTERM" or "term").
+func ParseSignal(name string) (os.Signal, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(key, "SIG") {
+		key = "SIG" + key
+	}
+	sig, ok := signals[key]
+	if !ok {
+		return nil, fmt.Errorf("execext: unknown signal %q", name)
+	}
+	return sig, nil
+}
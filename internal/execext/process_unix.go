@@ -0,0 +1,33 @@
+//go:build !windows
+
+package execext
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalProcess(p *os.Process, sig os.Signal, ownGroup bool) error {
+	if !ownGroup {
+		return p.Signal(sig)
+	}
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	// A negative PID sends the signal to the whole process group
+	return syscall.Kill(-p.Pid, s)
+}
+
+func exitSignal(err *exec.ExitError) (int, bool) {
+	status, ok := err.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return int(status.Signal()), true
+}
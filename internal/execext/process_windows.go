@@ -0,0 +1,25 @@
+//go:build windows
+
+package execext
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Process groups are not supported on Windows, so processes are always
+// started in the same group as Task.
+func setProcessGroup(*exec.Cmd) {}
+
+func signalProcess(p *os.Process, sig os.Signal, _ bool) error {
+	// Windows only supports sending os.Kill to a process
+	if sig != os.Kill {
+		return errors.New("execext: signal not supported on windows")
+	}
+	return p.Kill()
+}
+
+func exitSignal(*exec.ExitError) (int, bool) {
+	return 0, false
+}
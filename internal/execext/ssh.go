@@ -0,0 +1,248 @@
+package execext
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// SSHOptions describes the remote host on which a command is run over SSH
+// instead of the embedded interpreter.
+type SSHOptions struct {
+	// Name is the name of the host in the inventory. It is only used in
+	// error messages.
+	Name    string
+	Address string
+	Port    int
+	User    string
+	// IdentityFile is the private key used to authenticate. If empty, the
+	// keys of the running SSH agent and the default keys in ~/.ssh are used.
+	IdentityFile string
+	// KnownHosts is the known_hosts file used to verify the host key.
+	// Defaults to ~/.ssh/known_hosts.
+	KnownHosts string
+	// Insecure disables the verification of the host key.
+	Insecure bool
+	// Dir is the directory in which the command is run on the remote host.
+	// If empty, the command is run in the user's home directory.
+	Dir string
+	// Env is the list of "KEY=value" variables exported before running the
+	// command. The local environment is not passed through.
+	Env []string
+}
+
+// defaultIdentityFiles are the keys in ~/.ssh that are tried when no identity
+// file is configured.
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// runSSH runs the command of the given options on a remote host.
+func runSSH(ctx context.Context, opts *RunCommandOptions) error {
+	if opts.Container != nil {
+		return errors.New("execext: commands run over SSH cannot use a container")
+	}
+	if opts.Interpreter != "" {
+		return errors.New("execext: an interpreter cannot be used for commands run over SSH")
+	}
+	if len(opts.BashOpts) > 0 {
+		return errors.New("execext: shopt is not supported for commands run over SSH")
+	}
+
+	config, closeAuth, err := sshClientConfig(opts.SSH)
+	if err != nil {
+		return err
+	}
+	defer closeAuth()
+	port := opts.SSH.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(opts.SSH.Address, strconv.Itoa(port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("execext: unable to connect to host %q: %w", opts.SSH.Name, err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("execext: unable to connect to host %q: %w", opts.SSH.Name, err)
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	session.Stdin = opts.Stdin
+	// Stdout and stderr are copied concurrently by the session and are often
+	// the same writer
+	var mu sync.Mutex
+	session.Stdout = &syncWriter{mu: &mu, w: opts.Stdout}
+	session.Stderr = &syncWriter{mu: &mu, w: opts.Stderr}
+
+	stopf := context.AfterFunc(ctx, func() {
+		_ = session.Signal(ssh.SIGINT)
+		_ = client.Close()
+	})
+	defer stopf()
+
+	err = session.Run(remoteScript(opts))
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return interp.ExitStatus(exitErr.ExitStatus())
+	}
+	return err
+}
+
+// remoteScript returns the script that is sent to the remote host to run the
+// command of the given options.
+func remoteScript(opts *RunCommandOptions) string {
+	var b strings.Builder
+	for _, opt := range opts.PosixOpts {
+		if len(opt) == 1 {
+			fmt.Fprintf(&b, "set -%s\n", opt)
+		} else {
+			fmt.Fprintf(&b, "set -o %s\n", opt)
+		}
+	}
+	for _, kv := range opts.SSH.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		fmt.Fprintf(&b, "export %s=%s\n", k, quote(v))
+	}
+	if opts.SSH.Dir != "" {
+		fmt.Fprintf(&b, "cd %s\n", quote(opts.SSH.Dir))
+	}
+	b.WriteString(opts.Command)
+	return b.String()
+}
+
+type syncWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func quote(s string) string {
+	q, err := syntax.Quote(s, syntax.LangPOSIX)
+	if err != nil {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	return q
+}
+
+// sshClientConfig returns the configuration of the SSH client for the given
+// host, and a function that closes the connection to the SSH agent once the
+// session is over.
+func sshClientConfig(opts *SSHOptions) (*ssh.ClientConfig, func(), error) {
+	user := opts.User
+	if user == "" {
+		user = cmp.Or(os.Getenv("USER"), os.Getenv("USERNAME"))
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	if opts.Insecure {
+		hostKeyCallback = ssh.InsecureIgnoreHostKey() //nolint:gosec
+	} else {
+		knownHostsFile := opts.KnownHosts
+		if knownHostsFile == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, nil, err
+			}
+			knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+		knownHostsFile, err := ExpandLiteral(knownHostsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hostKeyCallback, err = knownhosts.New(knownHostsFile); err != nil {
+			return nil, nil, fmt.Errorf("execext: unable to read known hosts for host %q: %w", opts.Name, err)
+		}
+	}
+
+	auth, closeAuth, err := sshAuthMethods(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	}, closeAuth, nil
+}
+
+// sshAuthMethods returns the methods used to authenticate to the given host,
+// and a function that closes the connection to the SSH agent, if any.
+func sshAuthMethods(opts *SSHOptions) ([]ssh.AuthMethod, func(), error) {
+	if opts.IdentityFile != "" {
+		path, err := ExpandLiteral(opts.IdentityFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, err := readSigner(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("execext: unable to read identity file for host %q: %w", opts.Name, err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, func() {}, nil
+	}
+
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			closeAgent = func() { _ = conn.Close() }
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		var signers []ssh.Signer
+		for _, name := range defaultIdentityFiles {
+			if signer, err := readSigner(filepath.Join(home, ".ssh", name)); err == nil {
+				signers = append(signers, signer)
+			}
+		}
+		if len(signers) > 0 {
+			methods = append(methods, ssh.PublicKeys(signers...))
+		}
+	}
+	if len(methods) == 0 {
+		closeAgent()
+		return nil, nil, fmt.Errorf("execext: no SSH keys available to authenticate to host %q", opts.Name)
+	}
+	return methods, closeAgent, nil
+}
+
+func readSigner(path string) (ssh.Signer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(b)
+}
@@ -88,7 +88,13 @@ func (*ChecksumChecker) Kind() string {
 }
 
 func (c *ChecksumChecker) checksum(t *ast.Task) (string, error) {
-	sources, err := Globs(t.Dir, t.Sources)
+	return Checksum(t.Dir, t.Sources)
+}
+
+// Checksum returns the checksum of the names and the contents of the files
+// that match the given globs
+func Checksum(dir string, globs []*ast.Glob) (string, error) {
+	sources, err := Globs(dir, globs)
 	if err != nil {
 		return "", err
 	}
@@ -2,6 +2,7 @@ package flags
 
 import (
 	"cmp"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -15,7 +16,9 @@ import (
 	"github.com/vikbert/taskr/v3/errors"
 	"github.com/vikbert/taskr/v3/experiments"
 	"github.com/vikbert/taskr/v3/internal/env"
+	"github.com/vikbert/taskr/v3/internal/execext"
 	"github.com/vikbert/taskr/v3/internal/sort"
+	"github.com/vikbert/taskr/v3/taskfile"
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 	"github.com/vikbert/taskr/v3/taskrc"
 	taskrcast "github.com/vikbert/taskr/v3/taskrc/ast"
@@ -65,6 +68,7 @@ var (
 	AssumeYes           bool
 	Dry                 bool
 	Summary             bool
+	ExplainVars         bool
 	ExitCode            bool
 	Parallel            bool
 	Concurrency         int
@@ -78,13 +82,27 @@ var (
 	Experiments         bool
 	Download            bool
 	Offline             bool
+	Lock                bool
 	TrustedHosts        []string
+	TrustedKeys         []string
+	RemoteAuth          map[string]*taskfile.Auth
 	ClearCache          bool
 	Timeout             time.Duration
 	CacheExpiryDuration time.Duration
 	RemoteCacheDir      string
+	ShutdownSequence    []task.ShutdownStep
+	DeferTimeout        time.Duration
+	ContainerRuntime    string
+	Hosts               map[string]task.Host
+	TargetHosts         []string
+	Strict              bool
+	Environment         string
+	Profile             string
+	LogDir              string
 )
 
+var shutdownErr error
+
 func init() {
 	// Config files can enable experiments which alter the availability and/or
 	// behavior of some flags, so we need to parse the experiments before the
@@ -135,19 +153,35 @@ func init() {
 	pflag.BoolVarP(&Parallel, "parallel", "p", false, "Executes tasks provided on command line in parallel.")
 	pflag.BoolVarP(&Dry, "dry", "n", false, "Compiles and prints tasks in the order that they would be run, without executing them.")
 	pflag.BoolVar(&Summary, "summary", false, "Show summary about a task.")
+	pflag.BoolVar(&ExplainVars, "vars", false, "Show the variables of a task and where their values come from.")
+	pflag.BoolVar(&ExplainVars, "explain-vars", false, "Alias of --vars.")
+	_ = pflag.CommandLine.MarkHidden("explain-vars")
 	pflag.BoolVarP(&ExitCode, "exit-code", "x", false, "Pass-through the exit code of the task command.")
 	pflag.StringVarP(&Dir, "dir", "d", "", "Sets the directory in which Task will execute and look for a Taskfile.")
 	pflag.StringVarP(&Entrypoint, "taskfile", "t", "", `Choose which Taskfile to run. Defaults to "Taskfile.yml".`)
-	pflag.StringVarP(&Output.Name, "output", "o", "", "Sets output style: [interleaved|group|prefixed].")
+	pflag.StringVarP(&Output.Name, "output", "o", "", "Sets output style: [interleaved|group|prefixed|github|gitlab|ci].")
 	pflag.StringVar(&Output.Group.Begin, "output-group-begin", "", "Message template to print before a task's grouped output.")
 	pflag.StringVar(&Output.Group.End, "output-group-end", "", "Message template to print after a task's grouped output.")
 	pflag.BoolVar(&Output.Group.ErrorOnly, "output-group-error-only", false, "Swallow output from successful tasks.")
+	pflag.StringVar(&Output.Timestamps, "output-timestamps", "", "Prefixes each line of output with the time: [absolute|elapsed].")
+	pflag.BoolVar(&Output.Durations, "output-durations", false, "Prints how long each task took once it finishes.")
 	pflag.BoolVarP(&Color, "color", "c", getConfig(config, func() *bool { return config.Color }, true), "Colored output. Enabled by default. Set flag to false or use NO_COLOR=1 to disable.")
 	pflag.IntVarP(&Concurrency, "concurrency", "C", getConfig(config, func() *int { return config.Concurrency }, 0), "Limit number of tasks to run concurrently.")
 	pflag.DurationVarP(&Interval, "interval", "I", 0, "Interval to watch for changes.")
 	pflag.BoolVarP(&Failfast, "failfast", "F", getConfig(config, func() *bool { return &config.Failfast }, false), "When running tasks in parallel, stop all tasks if one fails.")
 	pflag.BoolVarP(&Global, "global", "g", false, "Runs global Taskfile, from $HOME/{T,t}askfile.{yml,yaml}.")
 	pflag.BoolVar(&Experiments, "experiments", false, "Lists all the available experiments and whether or not they are enabled.")
+	pflag.DurationVar(&DeferTimeout, "defer-timeout", getConfig(config, func() *time.Duration { return config.Shutdown.DeferTimeout }, 30*time.Second), "Time given to deferred commands to finish after an interrupt signal is received.")
+	pflag.StringVar(&ContainerRuntime, "container-runtime", getConfig(config, func() *string { return config.Container.Runtime }, cmp.Or(env.GetTaskEnv("CONTAINER_RUNTIME"), execext.DefaultContainerRuntime)), "Container CLI used to run tasks that declare a container [docker|podman].")
+	pflag.StringSliceVar(&TargetHosts, "host", nil, "Runs the commands of the tasks over SSH on the given hosts of the inventory (comma-separated).")
+	pflag.StringVar(&Environment, "env", "", "Layers the .env.<name> and .env.<name>.local variants of the dotenv files on top of them.")
+	pflag.StringVar(&Profile, "profile", env.GetTaskEnv("PROFILE"), "Overrides the variables of the Taskfile with those of the given profile.")
+	pflag.StringVar(&LogDir, "log-dir", env.GetTaskEnv("LOG_DIR"), "Saves the output of every task to a file in the given directory.")
+	pflag.BoolVar(&Strict, "strict", getConfig(config, func() *bool { return config.Strict }, false), "Fails when templates use undefined variables.")
+	if config != nil {
+		ShutdownSequence, shutdownErr = parseShutdownSequence(config.Shutdown.Escalation)
+		Hosts = parseHosts(config.Hosts)
+	}
 
 	// Gentle force experiment will override the force flag and add a new force-all flag
 	if experiments.GentleForce.Enabled() {
@@ -164,8 +198,13 @@ func init() {
 		pflag.StringSliceVar(&TrustedHosts, "trusted-hosts", getConfig(config, func() *[]string { return &config.Remote.TrustedHosts }, nil), "List of trusted hosts for remote Taskfiles (comma-separated).")
 		pflag.DurationVar(&Timeout, "timeout", getConfig(config, func() *time.Duration { return config.Remote.Timeout }, time.Second*10), "Timeout for downloading remote Taskfiles.")
 		pflag.BoolVar(&ClearCache, "clear-cache", false, "Clear the remote cache.")
+		pflag.BoolVar(&Lock, "lock", false, "Resolves every remote Taskfile and writes them to Taskfile.lock.")
 		pflag.DurationVar(&CacheExpiryDuration, "expiry", getConfig(config, func() *time.Duration { return config.Remote.CacheExpiry }, 0), "Expiry duration for cached remote Taskfiles.")
 		pflag.StringVar(&RemoteCacheDir, "remote-cache-dir", getConfig(config, func() *string { return config.Remote.CacheDir }, env.GetTaskEnv("REMOTE_DIR")), "Directory to cache remote Taskfiles.")
+		if config != nil {
+			TrustedKeys = config.Remote.TrustedKeys
+			RemoteAuth = parseRemoteAuth(config.Remote.Auth)
+		}
 	}
 	pflag.Parse()
 
@@ -202,6 +241,14 @@ func Validate() error {
 		return errors.New("task: You can't set both --download and --clear-cache flags")
 	}
 
+	if Lock && Offline {
+		return errors.New("task: You can't set both --lock and --offline flags")
+	}
+
+	if shutdownErr != nil {
+		return shutdownErr
+	}
+
 	if Global && Dir != "" {
 		return errors.New("task: You can't set both --global and --dir")
 	}
@@ -272,7 +319,10 @@ func (o *flagsOption) ApplyToExecutor(e *task.Executor) {
 		task.WithInsecure(Insecure),
 		task.WithDownload(Download),
 		task.WithOffline(Offline),
+		task.WithLock(Lock),
 		task.WithTrustedHosts(TrustedHosts),
+		task.WithTrustedKeys(TrustedKeys),
+		task.WithRemoteAuth(RemoteAuth),
 		task.WithTimeout(Timeout),
 		task.WithCacheExpiryDuration(CacheExpiryDuration),
 		task.WithRemoteCacheDir(RemoteCacheDir),
@@ -283,6 +333,7 @@ func (o *flagsOption) ApplyToExecutor(e *task.Executor) {
 		task.WithAssumeYes(AssumeYes),
 		task.WithDry(Dry || Status),
 		task.WithSummary(Summary),
+		task.WithExplainVars(ExplainVars),
 		task.WithParallel(Parallel),
 		task.WithColor(Color),
 		task.WithConcurrency(Concurrency),
@@ -291,9 +342,72 @@ func (o *flagsOption) ApplyToExecutor(e *task.Executor) {
 		task.WithTaskSorter(sorter),
 		task.WithVersionCheck(true),
 		task.WithFailfast(Failfast),
+		task.WithShutdownSequence(ShutdownSequence),
+		task.WithDeferTimeout(DeferTimeout),
+		task.WithContainerRuntime(ContainerRuntime),
+		task.WithHosts(Hosts),
+		task.WithTargetHosts(TargetHosts),
+		task.WithStrict(Strict),
+		task.WithEnvironment(Environment),
+		task.WithProfile(Profile),
+		task.WithLogDir(LogDir),
 	)
 }
 
+// parseShutdownSequence converts the shutdown escalation steps from the config
+// files into a sequence that can be used by the executor.
+func parseShutdownSequence(steps []taskrcast.ShutdownStep) ([]task.ShutdownStep, error) {
+	sequence := make([]task.ShutdownStep, 0, len(steps))
+	for _, step := range steps {
+		sig, err := execext.ParseSignal(step.Signal)
+		if err != nil {
+			return nil, fmt.Errorf("task: Invalid shutdown escalation in config: %w", err)
+		}
+		sequence = append(sequence, task.ShutdownStep{Signal: sig, After: step.After})
+	}
+	return sequence, nil
+}
+
+// parseHosts converts the host inventory from the config files into hosts that
+// can be used by the executor. Hosts without an address use their name instead.
+func parseHosts(hosts map[string]taskrcast.Host) map[string]task.Host {
+	if len(hosts) == 0 {
+		return nil
+	}
+	inventory := make(map[string]task.Host, len(hosts))
+	for name, host := range hosts {
+		inventory[name] = task.Host{
+			Address:      cmp.Or(host.Address, name),
+			Port:         host.Port,
+			User:         host.User,
+			IdentityFile: host.IdentityFile,
+			KnownHosts:   host.KnownHosts,
+			Insecure:     host.Insecure,
+			Dir:          host.Dir,
+		}
+	}
+	return inventory
+}
+
+// parseRemoteAuth converts the per-host auth of remote Taskfiles from the config
+// files into the auth used by the Taskfile reader.
+func parseRemoteAuth(auth map[string]taskrcast.RemoteAuth) map[string]*taskfile.Auth {
+	if len(auth) == 0 {
+		return nil
+	}
+	hostAuth := make(map[string]*taskfile.Auth, len(auth))
+	for host, a := range auth {
+		hostAuth[host] = &taskfile.Auth{
+			TokenEnv:         a.TokenEnv,
+			Username:         a.Username,
+			PasswordEnv:      a.PasswordEnv,
+			Netrc:            a.Netrc,
+			CredentialHelper: a.CredentialHelper,
+		}
+	}
+	return hostAuth
+}
+
 // getConfig extracts a config value directly from a pointer field with a fallback default
 func getConfig[T any](config *taskrcast.TaskRC, fieldFunc func() *T, fallback T) T {
 	if config == nil {
@@ -0,0 +1,279 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vikbert/taskr/v3/internal/filepathext"
+	"github.com/vikbert/taskr/v3/internal/templater"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// The CI providers that have a dedicated output style
+const (
+	CIGitHub = "github"
+	CIGitLab = "gitlab"
+)
+
+// DetectCI returns the CI provider that Task is running on, or an empty string
+// if it is not running on a supported one.
+func DetectCI() string {
+	if ok, _ := strconv.ParseBool(os.Getenv("GITHUB_ACTIONS")); ok {
+		return CIGitHub
+	}
+	if ok, _ := strconv.ParseBool(os.Getenv("GITLAB_CI")); ok {
+		return CIGitLab
+	}
+	return ""
+}
+
+// Annotation is a failure reported to the CI provider
+type Annotation struct {
+	Title    string
+	Message  string
+	Location *ast.Location
+}
+
+// Annotator is implemented by the output styles that can report failures to
+// the CI provider
+type Annotator interface {
+	Annotate(w io.Writer, a Annotation) error
+}
+
+// CI wraps the output of each task invocation in a collapsible section of the
+// CI provider. The output is streamed as the commands write it, unless the
+// task runs in parallel with other tasks: its section is then buffered until
+// the task finishes, so that parallel tasks don't end up in each other's
+// sections.
+type CI struct {
+	Provider string
+	sections *atomic.Uint64
+	stream   *sectionStream
+}
+
+// Sectioner is implemented by the output styles that group the output of each
+// task invocation, rather than of each command
+type Sectioner interface {
+	// Section returns the writers that the output of the task, including the
+	// commands it runs, is written to until the section is closed. The
+	// section is buffered if the task runs in parallel with other tasks.
+	Section(stdOut, stdErr io.Writer, name string, parallel bool) (io.Writer, io.Writer, CloseFunc)
+}
+
+func NewCI(provider string) CI {
+	return CI{
+		Provider: provider,
+		sections: &atomic.Uint64{},
+		stream:   &sectionStream{},
+	}
+}
+
+// WrapWriter returns the given writers as is, since the output is grouped by
+// the section of the task instead.
+func (c CI) WrapWriter(stdOut, stdErr io.Writer, _ string, _ *templater.Cache) (io.Writer, io.Writer, CloseFunc) {
+	return stdOut, stdErr, func(error) error { return nil }
+}
+
+func (c CI) Section(stdOut, stdErr io.Writer, name string, parallel bool) (io.Writer, io.Writer, CloseFunc) {
+	s := &section{ci: c, name: name, writer: stdOut, buffered: parallel, start: time.Now()}
+	return &sectionWriter{section: s, writer: stdOut}, &sectionWriter{section: s, writer: stdErr}, s.close
+}
+
+// sectionStream serializes the sections written to the output. A section that
+// starts while another one is open ends it first, and the other one is opened
+// again on its next write, since sections can't be nested.
+type sectionStream struct {
+	mutex   sync.Mutex
+	current *section
+}
+
+// section is the output of a task invocation. The commands of the task write
+// to it concurrently when they run on several hosts.
+type section struct {
+	ci       CI
+	name     string
+	writer   io.Writer
+	buffered bool
+	start    time.Time
+
+	// The buffered output, in the order it was written to either writer
+	mutex  sync.Mutex
+	chunks []sectionChunk
+
+	// The name of the section on GitLab
+	id string
+}
+
+type sectionChunk struct {
+	writer io.Writer
+	p      []byte
+}
+
+type sectionWriter struct {
+	section *section
+	writer  io.Writer
+}
+
+func (sw *sectionWriter) Write(p []byte) (int, error) {
+	s := sw.section
+	if s.buffered {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.chunks = append(s.chunks, sectionChunk{writer: sw.writer, p: bytes.Clone(p)})
+		return len(p), nil
+	}
+
+	stream := s.ci.stream
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if err := s.open(time.Now(), false); err != nil {
+		return 0, err
+	}
+	return sw.writer.Write(p)
+}
+
+// open makes the section the current one of the stream, ending the current
+// one if it's another section. The stream must be locked.
+func (s *section) open(start time.Time, collapsed bool) error {
+	stream := s.ci.stream
+	if stream.current == s {
+		return nil
+	}
+	if current := stream.current; current != nil {
+		stream.current = nil
+		if _, err := io.WriteString(current.writer, current.end()); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(s.writer, s.begin(start, collapsed)); err != nil {
+		return err
+	}
+	stream.current = s
+	return nil
+}
+
+// close ends the section, or writes it as a whole if it was buffered. On
+// GitLab, buffered sections are only collapsed if the task succeeded.
+func (s *section) close(taskErr error) error {
+	stream := s.ci.stream
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if s.buffered {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if len(s.chunks) == 0 {
+			return nil
+		}
+		if err := s.open(s.start, taskErr == nil); err != nil {
+			return err
+		}
+		for _, chunk := range s.chunks {
+			if _, err := chunk.writer.Write(chunk.p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if stream.current != s {
+		return nil
+	}
+	stream.current = nil
+	_, writeErr := io.WriteString(s.writer, s.end())
+	return writeErr
+}
+
+// begin returns the line that begins the section on the CI provider
+func (s *section) begin(start time.Time, collapsed bool) string {
+	switch s.ci.Provider {
+	case CIGitHub:
+		return fmt.Sprintf("::group::%s\n", s.name)
+	case CIGitLab:
+		// Sections need a unique name, even when a section is opened again
+		s.id = fmt.Sprintf("task_%d_%s", s.ci.sections.Add(1), sectionNameReplacer.ReplaceAllString(s.name, "_"))
+		return fmt.Sprintf("\x1b[0Ksection_start:%d:%s[collapsed=%t]\r\x1b[0K%s\n", start.Unix(), s.id, collapsed, s.name)
+	}
+	return ""
+}
+
+// end returns the line that ends the section on the CI provider
+func (s *section) end() string {
+	switch s.ci.Provider {
+	case CIGitHub:
+		return "::endgroup::\n"
+	case CIGitLab:
+		return fmt.Sprintf("\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), s.id)
+	}
+	return ""
+}
+
+var sectionNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// Annotate writes the given failure as an error annotation of GitHub Actions,
+// or as a highlighted line on GitLab which has no annotations.
+func (c CI) Annotate(w io.Writer, a Annotation) error {
+	var file string
+	var line, column int
+	if a.Location != nil && a.Location.Taskfile != "" {
+		file = filepathext.TryAbsToRel(a.Location.Taskfile)
+		line, column = a.Location.Line, a.Location.Column
+	}
+
+	switch c.Provider {
+	case CIGitHub:
+		var props []string
+		if file != "" {
+			props = append(props, "file="+escapeGitHubProperty(file))
+			if line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", line), fmt.Sprintf("col=%d", column))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty(a.Title))
+		_, err := fmt.Fprintf(w, "::error %s::%s\n", strings.Join(props, ","), escapeGitHubData(a.Message))
+		return err
+	case CIGitLab:
+		location := ""
+		if file != "" {
+			location = file + ": "
+			if line > 0 {
+				location = fmt.Sprintf("%s:%d:%d: ", file, line, column)
+			}
+		}
+		_, err := fmt.Fprintf(w, "\x1b[31;1m%s%s: %s\x1b[0m\n", location, a.Title, a.Message)
+		return err
+	}
+	return nil
+}
+
+// escapeGitHubData escapes the message of a workflow command
+func escapeGitHubData(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '%':
+			b.WriteString("%25")
+		case '\r':
+			b.WriteString("%0D")
+		case '\n':
+			b.WriteString("%0A")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// escapeGitHubProperty escapes the value of a property of a workflow command
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -3,6 +3,7 @@ package output
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/vikbert/taskr/v3/internal/logger"
 	"github.com/vikbert/taskr/v3/internal/templater"
@@ -17,6 +18,25 @@ type CloseFunc func(err error) error
 
 // Build the Output for the requested ast.Output.
 func BuildFor(o *ast.Output, logger *logger.Logger) (Output, error) {
+	output, err := buildStyle(o, logger)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Timestamps {
+	case "":
+		return output, nil
+	case ast.OutputTimestampsAbsolute, ast.OutputTimestampsElapsed:
+	default:
+		return nil, fmt.Errorf(`task: output timestamps %q not recognized`, o.Timestamps)
+	}
+	return Timestamped{
+		Output:     output,
+		Timestamps: o.Timestamps,
+		Start:      time.Now(),
+	}, nil
+}
+
+func buildStyle(o *ast.Output, logger *logger.Logger) (Output, error) {
 	switch o.Name {
 	case "interleaved", "":
 		if err := checkOutputGroupUnset(o); err != nil {
@@ -34,6 +54,19 @@ func BuildFor(o *ast.Output, logger *logger.Logger) (Output, error) {
 			return nil, err
 		}
 		return NewPrefixed(logger), nil
+	case CIGitHub, CIGitLab:
+		if err := checkOutputGroupUnset(o); err != nil {
+			return nil, err
+		}
+		return NewCI(o.Name), nil
+	case "ci":
+		if err := checkOutputGroupUnset(o); err != nil {
+			return nil, err
+		}
+		if provider := DetectCI(); provider != "" {
+			return NewCI(provider), nil
+		}
+		return Interleaved{}, nil
 	default:
 		return nil, fmt.Errorf(`task: output style %q not recognized`, o.Name)
 	}
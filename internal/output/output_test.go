@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
@@ -190,3 +191,120 @@ func TestPrefixedWithColor(t *testing.T) {
 		}
 	})
 }
+
+func TestCIGitHub(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	ci := output.NewCI(output.CIGitHub)
+	section, sectionErr, closeSection := ci.Section(&b, &b, "build", false)
+
+	// The output is streamed, and the group only begins with it
+	assert.Equal(t, "", b.String())
+	fmt.Fprintln(sectionErr, "task: [build] first")
+	stdOut, stdErr, cleanup := ci.WrapWriter(section, sectionErr, "build", nil)
+	fmt.Fprintln(stdOut, "out")
+	fmt.Fprintln(stdErr, "err")
+	require.NoError(t, cleanup(nil))
+	assert.Equal(t, "::group::build\ntask: [build] first\nout\nerr\n", b.String())
+
+	// A nested section ends the group, which begins again with the next output
+	nested, _, closeNested := ci.Section(&b, &b, "test", false)
+	fmt.Fprintln(nested, "ok")
+	require.NoError(t, closeNested(nil))
+	fmt.Fprintln(section, "out")
+
+	require.NoError(t, closeSection(nil))
+	assert.Equal(t, "::group::build\ntask: [build] first\nout\nerr\n::endgroup::\n::group::test\nok\n::endgroup::\n::group::build\nout\n::endgroup::\n", b.String())
+}
+
+func TestCIGitHubStderr(t *testing.T) {
+	t.Parallel()
+
+	var stdOut, stdErr bytes.Buffer
+	ci := output.NewCI(output.CIGitHub)
+	section, sectionErr, closeSection := ci.Section(&stdOut, &stdErr, "build", false)
+	fmt.Fprintln(section, "out")
+	fmt.Fprintln(sectionErr, "err")
+	require.NoError(t, closeSection(nil))
+	assert.Equal(t, "::group::build\nout\n::endgroup::\n", stdOut.String())
+	assert.Equal(t, "err\n", stdErr.String())
+}
+
+func TestCIGitHubParallel(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	ci := output.NewCI(output.CIGitHub)
+	api, _, closeAPI := ci.Section(&b, &b, "api", true)
+	web, _, closeWeb := ci.Section(&b, &b, "web", true)
+	fmt.Fprintln(api, "api 1")
+	fmt.Fprintln(web, "web 1")
+	fmt.Fprintln(api, "api 2")
+
+	// Parallel sections are buffered until they are closed
+	assert.Equal(t, "", b.String())
+	require.NoError(t, closeWeb(nil))
+	require.NoError(t, closeAPI(nil))
+	assert.Equal(t, "::group::web\nweb 1\n::endgroup::\n::group::api\napi 1\napi 2\n::endgroup::\n", b.String())
+}
+
+func TestCIGitLab(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	ci := output.NewCI(output.CIGitLab)
+	section, _, closeSection := ci.Section(&b, &b, "docs:build", true)
+	for _, cmd := range []string{"first", "second"} {
+		w, _, cleanup := ci.WrapWriter(section, io.Discard, "docs:build", nil)
+		fmt.Fprintln(w, cmd)
+		require.NoError(t, cleanup(nil))
+	}
+	require.NoError(t, closeSection(errors.New("failed")))
+	assert.Regexp(t, `^\x1b\[0Ksection_start:\d+:task_1_docs_build\[collapsed=false\]\r\x1b\[0Kdocs:build\nfirst\nsecond\n\x1b\[0Ksection_end:\d+:task_1_docs_build\r\x1b\[0K\n$`, b.String())
+
+	// Sections without output are left out
+	b.Reset()
+	_, _, closeSection = ci.Section(&b, &b, "lint", false)
+	require.NoError(t, closeSection(nil))
+	assert.Equal(t, "", b.String())
+}
+
+func TestCIAnnotate(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	require.NoError(t, output.NewCI(output.CIGitHub).Annotate(&b, output.Annotation{
+		Title:    "Task 'build' failed",
+		Message:  "exit status 1\n100% broken",
+		Location: &ast.Location{Taskfile: "Taskfile.yml", Line: 12, Column: 3},
+	}))
+	assert.Equal(t, "::error file=Taskfile.yml,line=12,col=3,title=Task 'build' failed::exit status 1%0A100%25 broken\n", b.String())
+
+	b.Reset()
+	require.NoError(t, output.NewCI(output.CIGitLab).Annotate(&b, output.Annotation{
+		Title:    "Task 'build' failed",
+		Message:  "exit status 1",
+		Location: &ast.Location{Taskfile: "Taskfile.yml", Line: 12, Column: 3},
+	}))
+	assert.Equal(t, "\x1b[31;1mTaskfile.yml:12:3: Task 'build' failed: exit status 1\x1b[0m\n", b.String())
+}
+
+func TestTimestamped(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	var o output.Output = output.Timestamped{
+		Output:     output.Group{},
+		Timestamps: ast.OutputTimestampsElapsed,
+		Start:      time.Now(),
+	}
+	stdOut, stdErr, cleanup := o.WrapWriter(&b, io.Discard, "", nil)
+
+	fmt.Fprint(stdOut, "foo\nba")
+	fmt.Fprintln(stdErr, "r")
+	fmt.Fprintln(stdOut, "baz")
+	assert.Equal(t, "", b.String())
+	require.NoError(t, cleanup(nil))
+	assert.Regexp(t, `^\[\+\d+(\.\d+)?m?s\] foo\n\[\+\d+(\.\d+)?m?s\] bar\n\[\+\d+(\.\d+)?m?s\] baz\n$`, b.String())
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/vikbert/taskr/v3/internal/templater"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// Timestamped prefixes each line written by the commands with the absolute
+// time or the time elapsed since Start. The time is taken when the line is
+// written, so it stays accurate with styles that buffer the output.
+type Timestamped struct {
+	Output
+	Timestamps string
+	Start      time.Time
+}
+
+func (t Timestamped) WrapWriter(stdOut, stdErr io.Writer, prefix string, cache *templater.Cache) (io.Writer, io.Writer, CloseFunc) {
+	stdOut, stdErr, closer := t.Output.WrapWriter(stdOut, stdErr, prefix, cache)
+	tw := &timestampWriter{writer: stdOut, stamp: t.stamp, lineStart: true}
+	if stdErr == stdOut {
+		return tw, tw, closer
+	}
+	return tw, &timestampWriter{writer: stdErr, stamp: t.stamp, lineStart: true}, closer
+}
+
+func (t Timestamped) stamp() string {
+	if t.Timestamps == ast.OutputTimestampsElapsed {
+		return "[+" + time.Since(t.Start).Round(time.Millisecond).String() + "] "
+	}
+	return "[" + time.Now().Format("15:04:05.000") + "] "
+}
+
+// AsAnnotator returns the given output style as an [Annotator], looking through
+// [Timestamped].
+func AsAnnotator(o Output) (Annotator, bool) {
+	if t, ok := o.(Timestamped); ok {
+		o = t.Output
+	}
+	a, ok := o.(Annotator)
+	return a, ok
+}
+
+// AsSectioner returns the given output style as a [Sectioner], looking through
+// [Timestamped].
+func AsSectioner(o Output) (Sectioner, bool) {
+	if t, ok := o.(Timestamped); ok {
+		o = t.Output
+	}
+	s, ok := o.(Sectioner)
+	return s, ok
+}
+
+type timestampWriter struct {
+	writer    io.Writer
+	stamp     func() string
+	mutex     sync.Mutex
+	lineStart bool
+}
+
+func (tw *timestampWriter) Write(p []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	var b bytes.Buffer
+	for rest := p; len(rest) > 0; {
+		if tw.lineStart {
+			b.WriteString(tw.stamp())
+			tw.lineStart = false
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			b.Write(rest)
+			break
+		}
+		b.Write(rest[:i+1])
+		rest = rest[i+1:]
+		tw.lineStart = true
+	}
+	if _, err := tw.writer.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,136 @@
+// Package secrets implements the built-in providers of secret variables.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/internal/env"
+	"github.com/vikbert/taskr/v3/internal/execext"
+)
+
+// Command runs the given command with the built-in shell interpreter and
+// returns its output without the trailing newline.
+func Command(ctx context.Context, command, dir string, environ []string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	opts := &execext.RunCommandOptions{
+		Command: command,
+		Dir:     dir,
+		Env:     environ,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	}
+	if err := execext.RunCommand(ctx, opts); err != nil {
+		return "", withStderr(fmt.Errorf("command failed: %w", err), &stderr)
+	}
+	return trimNewline(stdout.String()), nil
+}
+
+// Keyring reads the password of the given account of the given service from
+// the keyring of the operating system. It uses the "security" command on macOS
+// and the "secret-tool" command of libsecret on other Unix systems.
+func Keyring(ctx context.Context, service, account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return run(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "windows", "plan9":
+		return "", fmt.Errorf("the keyring is not supported on %s", runtime.GOOS)
+	default:
+		return run(ctx, "secret-tool", "lookup", "service", service, "account", account)
+	}
+}
+
+// File decrypts the given file and returns the value at the given dotted path
+// of the decrypted document, or the whole document if the key is empty. Files
+// ending with ".age" are decrypted with age and the identity file set in
+// TASK_AGE_IDENTITY, every other file with sops. The decrypted document is
+// read as a dotenv file if its name contains ".env" and as YAML or JSON
+// otherwise.
+func File(ctx context.Context, path, key string) (string, error) {
+	var (
+		content string
+		err     error
+	)
+	name := filepath.Base(path)
+	if strings.HasSuffix(name, ".age") {
+		identity := env.GetTaskEnv("AGE_IDENTITY")
+		if identity == "" {
+			return "", fmt.Errorf("TASK_AGE_IDENTITY must be set to decrypt %s", path)
+		}
+		name = strings.TrimSuffix(name, ".age")
+		content, err = run(ctx, "age", "--decrypt", "--identity", identity, path)
+	} else {
+		content, err = run(ctx, "sops", "--decrypt", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		return content, nil
+	}
+	return lookup(content, name, key)
+}
+
+func lookup(content, name, key string) (string, error) {
+	if strings.Contains(name, ".env") {
+		values, err := godotenv.Unmarshal(content)
+		if err != nil {
+			return "", err
+		}
+		value, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found", key)
+		}
+		return value, nil
+	}
+
+	var value any
+	if err := yaml.Unmarshal([]byte(content), &value); err != nil {
+		return "", err
+	}
+	for part := range strings.SplitSeq(key, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("key %q not found", key)
+		}
+		if value, ok = m[part]; !ok {
+			return "", fmt.Errorf("key %q not found", key)
+		}
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return "", fmt.Errorf("key %q is not a scalar value", key)
+	}
+	return fmt.Sprint(value), nil
+}
+
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", withStderr(fmt.Errorf("%s failed: %w", name, err), &stderr)
+	}
+	return trimNewline(stdout.String()), nil
+}
+
+func withStderr(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
+
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\r\n")
+	return strings.TrimSuffix(s, "\n")
+}
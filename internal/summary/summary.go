@@ -18,6 +18,16 @@ func PrintTasks(l *logger.Logger, t *ast.Taskfile, c []string) {
 	}
 }
 
+// PrintProfile prints the name of the selected profile, if any
+func PrintProfile(l *logger.Logger, profile string) {
+	if profile == "" {
+		return
+	}
+	l.Outf(logger.Default, "profile: ")
+	l.Outf(logger.Cyan, "%s\n", profile)
+	l.Outf(logger.Default, "\n")
+}
+
 func PrintSpaceBetweenSummaries(l *logger.Logger, i int) {
 	spaceRequired := i > 0
 	if !spaceRequired {
@@ -194,8 +204,13 @@ func printTaskEnv(l *logger.Logger, t *ast.Task) {
 }
 
 // formatVarValue formats a variable value based on its type.
-// Handles static values, shell commands (sh:), references (ref:), and maps.
+// Handles secrets, static values, shell commands (sh:), references (ref:), and maps.
 func formatVarValue(v ast.Var) string {
+	// Secret - never print where the secret is read from, it may be part of it
+	if v.Secret != nil {
+		return fmt.Sprintf("secret: %s (redacted)", v.Secret.Provider)
+	}
+
 	// Shell command - check this first before Value
 	// because dynamic vars may have both Sh and an empty Value
 	if v.Sh != nil {
@@ -238,12 +253,34 @@ func formatMap(m map[string]any, indent int) string {
 }
 
 func printTaskRequires(l *logger.Logger, t *ast.Task) {
-	if t.Requires == nil || len(t.Requires.Vars) == 0 {
+	if t.Requires == nil {
+		return
+	}
+	hasVars := len(t.Requires.Vars) > 0
+	hasProfile := t.Requires.Profile != nil && t.Requires.Profile.Required
+	if !hasVars && !hasProfile {
 		return
 	}
 
 	l.Outf(logger.Default, "\n")
 	l.Outf(logger.Default, "requires:\n")
+
+	if hasProfile {
+		if len(t.Requires.Profile.Enum) > 0 {
+			l.Outf(logger.Default, "  profile:\n")
+			for _, profile := range t.Requires.Profile.Enum {
+				l.Outf(logger.Yellow, "    - %s\n", profile)
+			}
+		} else {
+			l.Outf(logger.Default, "  profile: ")
+			l.Outf(logger.Yellow, "true\n")
+		}
+	}
+
+	if !hasVars {
+		return
+	}
+
 	l.Outf(logger.Default, "  vars:\n")
 
 	for _, v := range t.Requires.Vars {
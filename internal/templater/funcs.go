@@ -1,12 +1,21 @@
 package templater
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"maps"
 	"math/rand/v2"
+	goos "os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/uuid"
 	"go.yaml.in/yaml/v4"
@@ -15,6 +24,9 @@ import (
 
 	sprig "github.com/go-task/slim-sprig/v3"
 	"github.com/go-task/template"
+
+	"github.com/vikbert/taskr/v3/internal/execext"
+	"github.com/vikbert/taskr/v3/internal/filepathext"
 )
 
 var templateFuncs template.FuncMap
@@ -42,6 +54,11 @@ func init() {
 		"mustToYaml":   mustToYaml,
 		"uuid":         uuid.New,
 		"randIntN":     rand.IntN,
+		// Data
+		"semverCompare":     semverCompare,
+		"mustSemverCompare": mustSemverCompare,
+		"envOr":             envOr,
+		"mustEnvOr":         mustEnvOr,
 	}
 
 	// aliases
@@ -52,6 +69,10 @@ func init() {
 	taskFuncs["ToSlash"] = taskFuncs["toSlash"]
 	taskFuncs["ExeExt"] = taskFuncs["exeExt"]
 
+	// The functions that depend on the directory run in the current one,
+	// unless the cache has a directory
+	maps.Copy(taskFuncs, dirFuncs{}.funcMap())
+
 	templateFuncs = template.FuncMap(sprig.TxtFuncMap())
 	maps.Copy(templateFuncs, taskFuncs)
 }
@@ -130,3 +151,219 @@ func mustToYaml(v any) (string, error) {
 	}
 	return string(output), nil
 }
+
+func semverCompare(constraint, version string) bool {
+	ok, _ := mustSemverCompare(constraint, version)
+	return ok
+}
+
+// mustSemverCompare reports whether the given version satisfies the given
+// constraint, e.g. ">= 1.2.0, < 2".
+func mustSemverCompare(constraint, version string) (bool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
+
+func envOr(name, fallback string) string {
+	if value, ok := goos.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// mustEnvOr fails if the environment variable is not set and there is no
+// fallback value.
+func mustEnvOr(name, fallback string) (string, error) {
+	value := envOr(name, fallback)
+	if value == "" {
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	}
+	return value, nil
+}
+
+// dirFuncs are the functions that resolve relative paths, or run commands, in
+// a directory. An empty directory is the current one.
+type dirFuncs struct {
+	dir string
+}
+
+func (d dirFuncs) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"readFile":         d.readFile,
+		"mustReadFile":     d.mustReadFile,
+		"fileExists":       d.fileExists,
+		"mustFileExists":   d.mustFileExists,
+		"glob":             d.glob,
+		"mustGlob":         d.mustGlob,
+		"fileHash":         d.fileHash,
+		"mustFileHash":     d.mustFileHash,
+		"fromJsonFile":     d.fromJsonFile,
+		"mustFromJsonFile": d.mustFromJsonFile,
+		"toJsonFile":       d.toJsonFile,
+		"mustToJsonFile":   d.mustToJsonFile,
+		"gitBranch":        d.gitBranch,
+		"mustGitBranch":    d.mustGitBranch,
+		"gitCommit":        d.gitCommit,
+		"mustGitCommit":    d.mustGitCommit,
+	}
+}
+
+func (d dirFuncs) path(path string) string {
+	if d.dir == "" {
+		return path
+	}
+	return filepathext.SmartJoin(d.dir, path)
+}
+
+func (d dirFuncs) readFile(path string) string {
+	output, _ := d.mustReadFile(path)
+	return output
+}
+
+func (d dirFuncs) mustReadFile(path string) (string, error) {
+	b, err := goos.ReadFile(d.path(path))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d dirFuncs) fileExists(path string) bool {
+	exists, _ := d.mustFileExists(path)
+	return exists
+}
+
+// mustFileExists only fails if the existence of the file can't be determined,
+// e.g. because of missing permissions.
+func (d dirFuncs) mustFileExists(path string) (bool, error) {
+	_, err := goos.Stat(d.path(path))
+	if err == nil {
+		return true, nil
+	}
+	if goos.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (d dirFuncs) glob(pattern string) []string {
+	matches, _ := d.mustGlob(pattern)
+	return matches
+}
+
+// mustGlob returns the sorted paths of the existing files and directories that
+// match the given pattern. Patterns support "**" to match any number of
+// directories. The paths are relative to the directory if the pattern is.
+func (d dirFuncs) mustGlob(pattern string) ([]string, error) {
+	fields, err := execext.ExpandFields(d.path(pattern))
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if _, err := goos.Stat(field); err != nil {
+			continue
+		}
+		if d.dir != "" && !filepathext.IsAbs(pattern) {
+			if rel, err := filepath.Rel(d.dir, field); err == nil {
+				field = rel
+			}
+		}
+		matches = append(matches, field)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (d dirFuncs) fileHash(path string) string {
+	output, _ := d.mustFileHash(path)
+	return output
+}
+
+// mustFileHash returns the hex-encoded SHA-256 checksum of the given file.
+func (d dirFuncs) mustFileHash(path string) (string, error) {
+	b, err := goos.ReadFile(d.path(path))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (d dirFuncs) fromJsonFile(path string) any {
+	output, _ := d.mustFromJsonFile(path)
+	return output
+}
+
+func (d dirFuncs) mustFromJsonFile(path string) (any, error) {
+	b, err := goos.ReadFile(d.path(path))
+	if err != nil {
+		return nil, err
+	}
+	var output any
+	if err := json.Unmarshal(b, &output); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return output, nil
+}
+
+func (d dirFuncs) toJsonFile(path string, v any) string {
+	output, _ := d.mustToJsonFile(path, v)
+	return output
+}
+
+// mustToJsonFile writes the given value as indented JSON to the given file,
+// and returns its path.
+func (d dirFuncs) mustToJsonFile(path string, v any) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if err := goos.WriteFile(d.path(path), append(b, '\n'), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (d dirFuncs) gitBranch() string {
+	output, _ := d.mustGitBranch()
+	return output
+}
+
+// mustGitBranch returns the name of the branch checked out in the directory,
+// or "HEAD" if it is detached.
+func (d dirFuncs) mustGitBranch() (string, error) {
+	return d.git("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+func (d dirFuncs) gitCommit() string {
+	output, _ := d.mustGitCommit()
+	return output
+}
+
+// mustGitCommit returns the hash of the commit checked out in the directory.
+func (d dirFuncs) mustGitCommit() (string, error) {
+	return d.git("rev-parse", "HEAD")
+}
+
+func (d dirFuncs) git(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = d.dir
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"maps"
+	"regexp"
 	"strings"
+	"text/template/parse"
 
 	"github.com/go-task/template"
 
+	"github.com/vikbert/taskr/v3/errors"
 	"github.com/vikbert/taskr/v3/internal/deepcopy"
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 )
@@ -18,9 +21,19 @@ import (
 // return the zero value.
 type Cache struct {
 	Vars *ast.Vars
+	// Strict makes templates that use undefined variables fail with a
+	// [errors.TaskUndefinedVarError] instead of rendering an empty string.
+	Strict bool
+	// Dir is the directory that the functions that read files, or run git,
+	// resolve relative paths in. It defaults to the current directory.
+	Dir string
 
 	cacheMap map[string]any
 	err      error
+	// dirFuncs are the functions of the directory they were built for, which
+	// changes when the cache is reused for a task
+	dirFuncs    template.FuncMap
+	dirFuncsDir string
 }
 
 func (r *Cache) ResetCache() {
@@ -31,6 +44,136 @@ func (r *Cache) Err() error {
 	return r.err
 }
 
+func (r *Cache) newTemplate(name string) *template.Template {
+	t := template.New(name).Funcs(templateFuncs)
+	if r.Dir != "" {
+		if r.dirFuncs == nil || r.dirFuncsDir != r.Dir {
+			r.dirFuncs = dirFuncs{dir: r.Dir}.funcMap()
+			r.dirFuncsDir = r.Dir
+		}
+		t = t.Funcs(r.dirFuncs)
+	}
+	if r.Strict {
+		t = t.Option("missingkey=error")
+	}
+	return t
+}
+
+// missingKeyRegexp matches the error returned by templates that use a map key
+// that doesn't exist when the missingkey=error option is set.
+var missingKeyRegexp = regexp.MustCompile(`map has no entry for key "(.*)"`)
+
+// undefinedVarError turns the error returned by the given template for an
+// undefined variable into a [errors.TaskUndefinedVarError].
+func undefinedVarError(err error, tpl string) error {
+	matches := missingKeyRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return err
+	}
+	return &errors.TaskUndefinedVarError{Var: matches[1], Template: tpl}
+}
+
+// optionalVars returns the variables that a template in strict mode may use
+// even if they are undefined, since it tests them with if or with, or gives
+// them a default value.
+func optionalVars(tree *parse.Tree) []string {
+	if tree == nil {
+		return nil
+	}
+	var names []string
+	var walk func(node parse.Node, root bool)
+	// addFields adds the variables of the data that are arguments of the
+	// given commands
+	addFields := func(cmds []*parse.CommandNode) {
+		for _, cmd := range cmds {
+			for _, arg := range cmd.Args {
+				if field, ok := arg.(*parse.FieldNode); ok && len(field.Ident) == 1 {
+					names = append(names, field.Ident[0])
+				}
+			}
+		}
+	}
+	walkPipe := func(pipe *parse.PipeNode, root bool, condition bool) {
+		if pipe == nil {
+			return
+		}
+		if root && condition {
+			addFields(pipe.Cmds)
+		}
+		for i, cmd := range pipe.Cmds {
+			if root && isDefault(cmd) {
+				addFields([]*parse.CommandNode{cmd})
+				// The previous commands of the pipeline give its last argument
+				if i == 1 && len(pipe.Cmds[0].Args) == 1 {
+					addFields(pipe.Cmds[:1])
+				}
+			}
+			for _, arg := range cmd.Args {
+				if arg, ok := arg.(*parse.PipeNode); ok {
+					walk(arg, root)
+				}
+			}
+		}
+	}
+	walk = func(node parse.Node, root bool) {
+		switch node := node.(type) {
+		case *parse.ListNode:
+			if node == nil {
+				return
+			}
+			for _, n := range node.Nodes {
+				walk(n, root)
+			}
+		case *parse.ActionNode:
+			walkPipe(node.Pipe, root, false)
+		case *parse.PipeNode:
+			walkPipe(node, root, false)
+		case *parse.IfNode:
+			walkPipe(node.Pipe, root, true)
+			walk(node.List, root)
+			walk(node.ElseList, root)
+		// The dot is not the data anymore in the body of with and range
+		case *parse.WithNode:
+			walkPipe(node.Pipe, root, true)
+			walk(node.List, false)
+			walk(node.ElseList, root)
+		case *parse.RangeNode:
+			walkPipe(node.Pipe, root, false)
+			walk(node.List, false)
+			walk(node.ElseList, root)
+		}
+	}
+	walk(tree.Root, true)
+	return names
+}
+
+// isDefault reports whether the given command calls the default function
+func isDefault(cmd *parse.CommandNode) bool {
+	if len(cmd.Args) == 0 {
+		return false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == "default"
+}
+
+// withOptionalVars returns the data with the optional variables of the given
+// template that are undefined set to nil, like they would be without strict
+// mode. The data is copied if it needs to be changed.
+func withOptionalVars(tpl *template.Template, data map[string]any) map[string]any {
+	cloned := false
+	for _, name := range optionalVars(tpl.Tree) {
+		if _, ok := data[name]; ok {
+			continue
+		}
+		if !cloned {
+			data = maps.Clone(data)
+			cloned = true
+		}
+		data[name] = nil
+	}
+	return data
+}
+
 func ResolveRef(ref string, cache *Cache) any {
 	// If there is already an error, do nothing
 	if cache.err != nil {
@@ -45,14 +188,18 @@ func ResolveRef(ref string, cache *Cache) any {
 	if ref == "." {
 		return cache.cacheMap
 	}
-	t, err := template.New("resolver").Funcs(templateFuncs).Parse(fmt.Sprintf("{{%s}}", ref))
+	t, err := cache.newTemplate("resolver").Parse(fmt.Sprintf("{{%s}}", ref))
 	if err != nil {
 		cache.err = err
 		return nil
 	}
-	val, err := t.Resolve(cache.cacheMap)
+	data := cache.cacheMap
+	if cache.Strict {
+		data = withOptionalVars(t, data)
+	}
+	val, err := t.Resolve(data)
 	if err != nil {
-		cache.err = err
+		cache.err = undefinedVarError(err, ref)
 		return nil
 	}
 	return val
@@ -82,13 +229,17 @@ func ReplaceWithExtra[T any](v T, cache *Cache, extra map[string]any) T {
 
 	// Traverse the value and parse any template variables
 	copy, err := deepcopy.TraverseStringsFunc(v, func(v string) (string, error) {
-		tpl, err := template.New("").Funcs(templateFuncs).Parse(v)
+		tpl, err := cache.newTemplate("").Parse(v)
 		if err != nil {
 			return v, err
 		}
+		data := data
+		if cache.Strict {
+			data = withOptionalVars(tpl, data)
+		}
 		var b bytes.Buffer
 		if err := tpl.Execute(&b, data); err != nil {
-			return v, err
+			return v, undefinedVarError(err, v)
 		}
 		return strings.ReplaceAll(b.String(), "<no value>", ""), nil
 	})
@@ -124,11 +275,13 @@ func ReplaceVarWithExtra(v ast.Var, cache *Cache, extra map[string]any) ast.Var
 		return ast.Var{Value: ResolveRef(v.Ref, cache)}
 	}
 	return ast.Var{
-		Value: ReplaceWithExtra(v.Value, cache, extra),
-		Sh:    ReplaceWithExtra(v.Sh, cache, extra),
-		Live:  v.Live,
-		Ref:   v.Ref,
-		Dir:   v.Dir,
+		Value:  ReplaceWithExtra(v.Value, cache, extra),
+		Sh:     ReplaceWithExtra(v.Sh, cache, extra),
+		Cache:  ReplaceWithExtra(v.Cache, cache, extra),
+		Secret: ReplaceWithExtra(v.Secret, cache, extra),
+		Live:   v.Live,
+		Ref:    v.Ref,
+		Dir:    v.Dir,
 	}
 }
 
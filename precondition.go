@@ -2,11 +2,13 @@ package task
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/vikbert/taskr/v3/errors"
 	"github.com/vikbert/taskr/v3/internal/env"
 	"github.com/vikbert/taskr/v3/internal/execext"
 	"github.com/vikbert/taskr/v3/internal/logger"
+	"github.com/vikbert/taskr/v3/internal/output"
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 )
 
@@ -23,6 +25,11 @@ func (e *Executor) areTaskPreconditionsMet(ctx context.Context, t *ast.Task) (bo
 		if err != nil {
 			if !errors.Is(err, context.Canceled) {
 				e.Logger.Errf(logger.Magenta, "task: %s\n", p.Msg)
+				e.annotate(output.Annotation{
+					Title:    fmt.Sprintf("Task '%s' precondition not met", t.Name()),
+					Message:  p.Msg,
+					Location: t.Location,
+				})
 			}
 			return false, ErrPreconditionFailed
 		}
@@ -0,0 +1,211 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ladicle/tabwriter"
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/vikbert/taskr/v3/internal/logger"
+	"github.com/vikbert/taskr/v3/taskfile"
+)
+
+// ListRemotes prints every remote Taskfile stored in the cache along with the
+// time it was fetched, its checksum and its size. The Taskfiles are not read,
+// so the cache is left untouched.
+func (e *Executor) ListRemotes() error {
+	if err := e.setupRemoteCache(); err != nil {
+		return err
+	}
+	entries, err := taskfile.ReadCacheEntries(e.TempDir.Remote)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		e.Logger.Outf(logger.Yellow, "task: No remote Taskfiles are cached\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(e.Stdout, MinColumnWidth, TabWidth, TabPadding, ' ', 0)
+	e.Logger.FOutf(w, logger.Default, "SOURCE\tFETCHED\tCHECKSUM\tSIZE\n")
+	for _, entry := range entries {
+		e.Logger.FOutf(w, logger.Green, "%s\t", sourceOf(entry))
+		e.Logger.FOutf(w, logger.Default, "%s\t%s\t%d B\n", fetchedAt(entry.Timestamp), shortChecksum(entry.Checksum), entry.Size)
+	}
+	return w.Flush()
+}
+
+// UpdateRemotes downloads a fresh copy of the remote Taskfiles included under
+// the given namespaces, or of every remote Taskfile if none are given, and
+// prints the ones that changed.
+func (e *Executor) UpdateRemotes(namespaces ...string) error {
+	if err := e.setupRemoteCache(); err != nil {
+		return err
+	}
+	before, err := taskfile.ReadCacheEntries(e.TempDir.Remote)
+	if err != nil {
+		return err
+	}
+
+	if len(namespaces) == 0 {
+		e.Download = true
+	}
+	e.downloadNamespaces = namespaces
+	if err := e.Setup(); err != nil {
+		return err
+	}
+
+	after, err := taskfile.ReadCacheEntries(e.TempDir.Remote)
+	if err != nil {
+		return err
+	}
+	var updated int
+	for _, entry := range after {
+		i := slices.IndexFunc(before, func(old *taskfile.CacheEntry) bool { return old.Key == entry.Key })
+		if i != -1 && before[i].Checksum == entry.Checksum {
+			continue
+		}
+		e.Logger.Outf(logger.Green, "task: Updated remote Taskfile %q\n", sourceOf(entry))
+		updated++
+	}
+	if updated == 0 {
+		e.Logger.Outf(logger.Green, "task: Remote Taskfiles are up to date\n")
+	}
+	return nil
+}
+
+// DiffRemotes downloads every remote Taskfile stored in the cache and prints
+// the changes made upstream since it was cached. The cache is not updated.
+func (e *Executor) DiffRemotes(ctx context.Context) error {
+	if err := e.setupRemoteCache(); err != nil {
+		return err
+	}
+	entries, err := taskfile.ReadCacheEntries(e.TempDir.Remote)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = taskfile.CleanGitCache()
+	}()
+
+	var changed int
+	for _, entry := range entries {
+		if entry.Source == "" {
+			e.Logger.VerboseErrf(logger.Yellow, "task: Skipping cache entry %q with unknown source\n", entry.Key)
+			continue
+		}
+		cached, err := entry.Read()
+		if err != nil {
+			return err
+		}
+		upstream, err := e.readRemote(ctx, entry.Source)
+		if err != nil {
+			return err
+		}
+		if string(cached) == string(upstream) {
+			continue
+		}
+		changed++
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(cached)),
+			B:        difflib.SplitLines(string(upstream)),
+			FromFile: entry.Source + " (cached)",
+			ToFile:   entry.Source + " (upstream)",
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		for line := range strings.Lines(diff) {
+			color := logger.Default
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				color = logger.Default
+			case strings.HasPrefix(line, "+"):
+				color = logger.Green
+			case strings.HasPrefix(line, "-"):
+				color = logger.Red
+			case strings.HasPrefix(line, "@@"):
+				color = logger.Cyan
+			}
+			e.Logger.Outf(color, "%s", line)
+		}
+	}
+	if changed == 0 {
+		e.Logger.Outf(logger.Green, "task: Cached remote Taskfiles are up to date\n")
+	}
+	return nil
+}
+
+// PruneRemotes removes the remote Taskfiles stored in the cache that are not
+// included by the Taskfile anymore.
+func (e *Executor) PruneRemotes() error {
+	if err := e.Setup(); err != nil {
+		return err
+	}
+	entries, err := taskfile.ReadCacheEntries(e.TempDir.Remote)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Source != "" && slices.Contains(e.taskfileLocations, entry.Source) {
+			continue
+		}
+		if err := entry.Remove(); err != nil {
+			return err
+		}
+		e.Logger.Outf(logger.Yellow, "task: Pruned remote Taskfile %q\n", sourceOf(entry))
+	}
+	return nil
+}
+
+// setupRemoteCache prepares the [Executor] to inspect the remote cache without
+// reading the Taskfiles.
+func (e *Executor) setupRemoteCache() error {
+	e.setupLogger()
+	if _, err := e.getRootNode(); err != nil {
+		return err
+	}
+	return e.setupTempDir()
+}
+
+// readRemote downloads the remote Taskfile at the given location.
+func (e *Executor) readRemote(ctx context.Context, location string) ([]byte, error) {
+	node, err := taskfile.NewNode(location, e.Dir, e.Insecure, taskfile.WithHostAuth(e.RemoteAuth))
+	if err != nil {
+		return nil, err
+	}
+	remote, ok := node.(taskfile.RemoteNode)
+	if !ok {
+		return nil, fmt.Errorf("task: %q is not a remote Taskfile", location)
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+	return remote.ReadContext(ctx)
+}
+
+func sourceOf(entry *taskfile.CacheEntry) string {
+	if entry.Source == "" {
+		return entry.Key
+	}
+	return entry.Source
+}
+
+func fetchedAt(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s (%s ago)", t.Local().Format(time.DateTime), time.Since(t).Round(time.Second))
+}
+
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
@@ -62,3 +62,20 @@ func (e *Executor) areTaskRequiredVarsAllowedValuesSet(t *ast.Task) error {
 
 	return nil
 }
+
+func (e *Executor) isTaskRequiredProfileSet(t *ast.Task) error {
+	if t.Requires == nil || t.Requires.Profile == nil || !t.Requires.Profile.Required {
+		return nil
+	}
+
+	allowed := t.Requires.Profile.Enum
+	if e.Profile != "" && (len(allowed) == 0 || slices.Contains(allowed, e.Profile)) {
+		return nil
+	}
+
+	return &errors.TaskProfileRequiredError{
+		TaskName:        t.Name(),
+		Profile:         e.Profile,
+		AllowedProfiles: allowed,
+	}
+}
@@ -0,0 +1,49 @@
+package task
+
+import (
+	"context"
+
+	"github.com/vikbert/taskr/v3/internal/secrets"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// SecretRequest is the secret that a [SecretProvider] is asked to resolve
+type SecretRequest struct {
+	// Source is what the secret is read from, e.g. the command to run, the
+	// service of the keyring or the path of the encrypted file
+	Source string
+	// Key selects the secret in the source
+	Key string
+	// Dir is the directory of the task or Taskfile that declares the secret
+	Dir string
+	// Env is the environment of the task, as KEY=value pairs
+	Env []string
+}
+
+// SecretProvider resolves the values of the variables that declare a secret
+// with its name as provider
+type SecretProvider interface {
+	ResolveSecret(ctx context.Context, req *SecretRequest) (string, error)
+}
+
+// SecretProviderFunc is a function that implements [SecretProvider]
+type SecretProviderFunc func(ctx context.Context, req *SecretRequest) (string, error)
+
+// ResolveSecret implements [SecretProvider]
+func (f SecretProviderFunc) ResolveSecret(ctx context.Context, req *SecretRequest) (string, error) {
+	return f(ctx, req)
+}
+
+func defaultSecretProviders() map[string]SecretProvider {
+	return map[string]SecretProvider{
+		ast.SecretProviderCommand: SecretProviderFunc(func(ctx context.Context, req *SecretRequest) (string, error) {
+			return secrets.Command(ctx, req.Source, req.Dir, req.Env)
+		}),
+		ast.SecretProviderKeyring: SecretProviderFunc(func(ctx context.Context, req *SecretRequest) (string, error) {
+			return secrets.Keyring(ctx, req.Source, req.Key)
+		}),
+		ast.SecretProviderFile: SecretProviderFunc(func(ctx context.Context, req *SecretRequest) (string, error) {
+			return secrets.File(ctx, req.Source, req.Key)
+		}),
+	}
+}
@@ -1,8 +1,10 @@
 package task
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
@@ -36,6 +38,12 @@ func (e *Executor) Setup() error {
 	if err := e.readTaskfile(node); err != nil {
 		return err
 	}
+	if err := e.applyProfile(); err != nil {
+		return err
+	}
+	if err := checkCachedVars(e.Taskfile); err != nil {
+		return err
+	}
 	e.setupStdFiles()
 	if err := e.setupOutput(); err != nil {
 		return err
@@ -51,11 +59,14 @@ func (e *Executor) Setup() error {
 	}
 	e.setupDefaults()
 	e.setupConcurrencyState()
+	if err := e.setupResources(); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (e *Executor) getRootNode() (taskfile.Node, error) {
-	node, err := taskfile.NewRootNode(e.Entrypoint, e.Dir, e.Insecure, e.Timeout)
+	node, err := taskfile.NewRootNode(e.Entrypoint, e.Dir, e.Insecure, e.Timeout, taskfile.WithHostAuth(e.RemoteAuth))
 	if os.IsNotExist(err) {
 		return nil, errors.TaskfileNotFoundError{
 			URI:     fsext.DefaultDir(e.Entrypoint, e.Dir),
@@ -71,6 +82,22 @@ func (e *Executor) getRootNode() (taskfile.Node, error) {
 }
 
 func (e *Executor) readTaskfile(node taskfile.Node) error {
+	// Local Taskfiles that haven't changed are read from the cache
+	cache := taskfile.NewParsedCache(
+		e.TempDir.Taskfile,
+		node.Location(),
+		version.GetVersionWithBuildInfo(),
+	)
+	// Downloading or locking the remote Taskfiles needs the includes to be
+	// read, which the cache skips
+	if !e.Lock && !e.Download && len(e.downloadNamespaces) == 0 {
+		if tf, locations, ok := cache.Read(); ok {
+			e.Taskfile = tf
+			e.taskfileLocations = locations
+			return nil
+		}
+	}
+
 	ctx, cf := context.WithTimeout(context.Background(), e.Timeout)
 	defer cf()
 	debugFunc := func(s string) {
@@ -79,15 +106,25 @@ func (e *Executor) readTaskfile(node taskfile.Node) error {
 	promptFunc := func(s string) error {
 		return e.Logger.Prompt(logger.Yellow, s, "n", "y", "yes")
 	}
+	lockfilePath := filepathext.SmartJoin(e.Dir, taskfile.LockfileName)
+	lockfile, err := e.readLockfile(lockfilePath)
+	if err != nil {
+		return err
+	}
 	reader := taskfile.NewReader(
 		taskfile.WithInsecure(e.Insecure),
 		taskfile.WithDownload(e.Download),
+		taskfile.WithDownloadNamespaces(e.downloadNamespaces),
 		taskfile.WithOffline(e.Offline),
 		taskfile.WithTrustedHosts(e.TrustedHosts),
+		taskfile.WithTrustedKeys(e.TrustedKeys),
+		taskfile.WithAuth(e.RemoteAuth),
 		taskfile.WithTempDir(e.TempDir.Remote),
 		taskfile.WithCacheExpiryDuration(e.CacheExpiryDuration),
 		taskfile.WithDebugFunc(debugFunc),
 		taskfile.WithPromptFunc(promptFunc),
+		taskfile.WithLockfile(lockfile),
+		taskfile.WithUpdateLock(e.Lock),
 	)
 	graph, err := reader.Read(ctx, node)
 	if err != nil {
@@ -96,12 +133,45 @@ func (e *Executor) readTaskfile(node taskfile.Node) error {
 		}
 		return err
 	}
+	if unmatched := reader.UnmatchedDownloadNamespaces(); len(unmatched) > 0 {
+		return fmt.Errorf("task: No remote Taskfile is included under the namespace(s) %s", strings.Join(unmatched, ", "))
+	}
+	if e.Lock {
+		if err := lockfile.Write(lockfilePath); err != nil {
+			return err
+		}
+	}
+	adjacencyMap, err := graph.AdjacencyMap()
+	if err != nil {
+		return err
+	}
+	e.taskfileLocations = slices.Collect(maps.Keys(adjacencyMap))
 	if e.Taskfile, err = graph.Merge(); err != nil {
 		return err
 	}
+	if err := cache.Write(graph, e.Taskfile); err != nil {
+		e.Logger.VerboseErrf(logger.Yellow, "task: Failed to cache Taskfile: %v\n", err)
+	}
 	return nil
 }
 
+// readLockfile returns the lockfile that pins the remote Taskfiles of the
+// project. When the lockfile is being updated, an empty one is returned so that
+// includes which were removed are dropped from it.
+func (e *Executor) readLockfile(path string) (*taskfile.Lockfile, error) {
+	if e.Lock {
+		return taskfile.NewLockfile(), nil
+	}
+	lockfile, err := taskfile.ReadLockfile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &errors.TaskfileInvalidError{URI: filepathext.TryAbsToRel(path), Err: err}
+	}
+	return lockfile, nil
+}
+
 func (e *Executor) setupFuzzyModel() {
 	if e.Taskfile == nil {
 		return
@@ -130,9 +200,16 @@ func (e *Executor) setupTempDir() error {
 
 	tempDir := env.GetTaskEnv("TEMP_DIR")
 	if tempDir == "" {
+		// The parsed Taskfiles are cached outside of the project, so that
+		// listing or completing tasks doesn't create a .task directory
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			cacheDir = os.TempDir()
+		}
 		e.TempDir = TempDir{
 			Remote:      filepathext.SmartJoin(e.Dir, ".task"),
 			Fingerprint: filepathext.SmartJoin(e.Dir, ".task"),
+			Taskfile:    filepath.Join(cacheDir, "task", "taskfile"),
 		}
 	} else if filepath.IsAbs(tempDir) || strings.HasPrefix(tempDir, "~") {
 		tempDir, err := execext.ExpandLiteral(tempDir)
@@ -144,12 +221,14 @@ func (e *Executor) setupTempDir() error {
 		e.TempDir = TempDir{
 			Remote:      tempDir,
 			Fingerprint: filepathext.SmartJoin(tempDir, projectName),
+			Taskfile:    filepath.Join(tempDir, "taskfile"),
 		}
 
 	} else {
 		e.TempDir = TempDir{
 			Remote:      filepathext.SmartJoin(e.Dir, tempDir),
 			Fingerprint: filepathext.SmartJoin(e.Dir, tempDir),
+			Taskfile:    filepath.Join(filepathext.SmartJoin(e.Dir, tempDir), "taskfile"),
 		}
 	}
 
@@ -195,7 +274,15 @@ func (e *Executor) setupLogger() {
 
 func (e *Executor) setupOutput() error {
 	if !e.OutputStyle.IsSet() {
-		e.OutputStyle = e.Taskfile.Output
+		// Timestamps and durations set on the command line apply to the
+		// style of the Taskfile
+		style := e.Taskfile.Output
+		style.Timestamps = cmp.Or(e.OutputStyle.Timestamps, style.Timestamps)
+		style.Durations = e.OutputStyle.Durations || style.Durations
+		e.OutputStyle = style
+	}
+	if !e.OutputStyle.IsSet() && e.EnableCIOutput {
+		e.OutputStyle.Name = output.DetectCI()
 	}
 
 	var err error
@@ -212,17 +299,36 @@ func (e *Executor) setupCompiler() error {
 		}
 	}
 
+	secretProviders := defaultSecretProviders()
+	maps.Copy(secretProviders, e.SecretProviders)
+
+	var profileVars *ast.Vars
+	if profile := e.Taskfile.Profiles[e.Profile]; e.Profile != "" && profile != nil {
+		profileVars = profile.Vars
+	}
 	e.Compiler = &Compiler{
-		Dir:            e.Dir,
-		Entrypoint:     e.Entrypoint,
-		UserWorkingDir: e.UserWorkingDir,
-		TaskfileEnv:    e.Taskfile.Env,
-		TaskfileVars:   e.Taskfile.Vars,
-		Logger:         e.Logger,
+		Dir:             e.Dir,
+		Entrypoint:      e.Entrypoint,
+		UserWorkingDir:  e.UserWorkingDir,
+		TaskfileEnv:     e.Taskfile.Env,
+		TaskfileVars:    e.Taskfile.Vars,
+		Logger:          e.Logger,
+		Strict:          e.Strict,
+		Profile:         e.Profile,
+		ProfileVars:     profileVars,
+		CacheDir:        filepath.Join(e.TempDir.Fingerprint, "vars"),
+		SecretProviders: secretProviders,
 	}
 	return nil
 }
 
+func (e *Executor) applyProfile() error {
+	if e.Profile == "" {
+		return nil
+	}
+	return e.Taskfile.ApplyProfile(e.Profile)
+}
+
 func (e *Executor) readDotEnvFiles() error {
 	if e.Taskfile == nil || len(e.Taskfile.Dotenv) == 0 {
 		return nil
@@ -237,7 +343,7 @@ func (e *Executor) readDotEnvFiles() error {
 		return err
 	}
 
-	env, err := taskfile.Dotenv(vars, e.Taskfile, e.Dir)
+	env, err := taskfile.Dotenv(vars, e.Taskfile, e.Dir, e.Environment)
 	if err != nil {
 		return err
 	}
@@ -274,6 +380,27 @@ func (e *Executor) setupConcurrencyState() {
 	}
 }
 
+// setupResources creates a semaphore for every resource pool declared in the
+// Taskfile and checks that tasks only use declared pools.
+func (e *Executor) setupResources() error {
+	e.resourceSemaphores = make(map[string]chan struct{}, len(e.Taskfile.Resources))
+	for name, capacity := range e.Taskfile.Resources {
+		e.resourceSemaphores[name] = make(chan struct{}, capacity)
+	}
+
+	for t := range e.Taskfile.Tasks.Values(nil) {
+		for _, name := range t.Uses {
+			if _, ok := e.resourceSemaphores[name]; !ok {
+				return errors.TaskfileInvalidError{
+					URI: t.Location.Taskfile,
+					Err: fmt.Errorf("task %q uses undeclared resource %q", t.Task, name),
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func (e *Executor) doVersionChecks() error {
 	if !e.EnableVersionCheck {
 		return nil
@@ -1,15 +1,72 @@
 package task
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/vikbert/taskr/v3/internal/logger"
 )
 
 const maxInterruptSignals = 3
 
+// A ShutdownStep is a single step of the escalation sequence that is used to
+// stop running commands once Task receives an interrupt signal.
+type ShutdownStep struct {
+	// Signal is sent to the running commands. If nil, the signal received by
+	// Task is forwarded instead.
+	Signal os.Signal
+	// After is the time to wait after the previous step before sending the
+	// signal. It is ignored for the first step.
+	After time.Duration
+}
+
+// DefaultShutdownSequence forwards the received signal to the running commands
+// and lets them decide how to shut down.
+var DefaultShutdownSequence = []ShutdownStep{{}}
+
+// shutdownState holds the state of a shutdown that was triggered by an
+// interrupt signal.
+type shutdownState struct {
+	mu          sync.Mutex
+	signals     int
+	step        int
+	running     []string
+	interrupted []string
+}
+
+func (s *shutdownState) inProgress() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signals > 0
+}
+
+// taskStarted records that the task with the given name is running.
+func (s *shutdownState) taskStarted(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = append(s.running, name)
+}
+
+// taskFinished records that the task with the given name is no longer
+// running. If it failed while a shutdown is in progress, it is considered to
+// have been interrupted.
+func (s *shutdownState) taskFinished(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := slices.Index(s.running, name); i != -1 {
+		s.running = slices.Delete(s.running, i, i+1)
+	}
+	if err != nil && s.signals > 0 && !slices.Contains(s.interrupted, name) {
+		s.interrupted = append(s.interrupted, name)
+	}
+}
+
 // NOTE(@andreynering): This function intercepts SIGINT and SIGTERM signals
 // so the Task process is not killed immediately and processes running have
 // time to do cleanup work.
@@ -23,10 +80,100 @@ func (e *Executor) InterceptInterruptSignals() {
 
 			if i+1 >= maxInterruptSignals {
 				e.Logger.Errf(logger.Red, "task: Signal received for the third time: %q. Forcing shutdown\n", sig)
+				e.processes.Signal(os.Kill, true)
+				e.deferredProcesses.Signal(os.Kill, true)
+				e.printInterruptedTasks()
 				os.Exit(1)
 			}
 
 			e.Logger.Outf(logger.Yellow, "task: Signal received: %q\n", sig)
+			e.shutdownState.mu.Lock()
+			e.shutdownState.signals++
+			e.shutdownState.mu.Unlock()
+
+			// The first signal starts the escalation sequence and any
+			// subsequent one skips straight to its next step
+			if i == 0 {
+				e.escalate(sig, 0)
+				go e.runShutdownSequence(sig)
+			} else {
+				e.escalate(sig, e.nextShutdownStep())
+			}
 		}
 	}()
 }
+
+// runShutdownSequence sends the signals defined by the shutdown sequence to
+// the running commands until they have all exited.
+func (e *Executor) runShutdownSequence(sig os.Signal) {
+	sequence := e.shutdownSequence()
+	for {
+		e.shutdownState.mu.Lock()
+		step := e.shutdownState.step + 1
+		e.shutdownState.mu.Unlock()
+		if step >= len(sequence) {
+			return
+		}
+		time.Sleep(sequence[step].After)
+		if e.processes.Len() == 0 {
+			return
+		}
+		e.escalate(sig, step)
+	}
+}
+
+func (e *Executor) nextShutdownStep() int {
+	e.shutdownState.mu.Lock()
+	defer e.shutdownState.mu.Unlock()
+	return min(e.shutdownState.step+1, len(e.shutdownSequence())-1)
+}
+
+// escalate sends the signal of the given step of the shutdown sequence to all
+// running commands. Deferred commands are never signalled, so they are able to
+// complete their cleanup work.
+func (e *Executor) escalate(received os.Signal, step int) {
+	e.shutdownState.mu.Lock()
+	if step < e.shutdownState.step {
+		e.shutdownState.mu.Unlock()
+		return
+	}
+	e.shutdownState.step = step
+	e.shutdownState.mu.Unlock()
+
+	sig := e.shutdownSequence()[step].Signal
+	if sig == nil {
+		sig = received
+	}
+	// On the first step, commands sharing our process group have already
+	// received the signal from the terminal
+	if n := e.processes.Signal(sig, step > 0); n > 0 {
+		e.Logger.VerboseErrf(logger.Yellow, "task: Sent %q to %d running command(s)\n", sig, n)
+	}
+}
+
+func (e *Executor) shutdownSequence() []ShutdownStep {
+	if len(e.ShutdownSequence) == 0 {
+		return DefaultShutdownSequence
+	}
+	return e.ShutdownSequence
+}
+
+// printInterruptedTasks prints the names of the tasks that did not complete
+// because Task received an interrupt signal.
+func (e *Executor) printInterruptedTasks() {
+	e.shutdownState.mu.Lock()
+	defer e.shutdownState.mu.Unlock()
+	if e.shutdownState.signals == 0 {
+		return
+	}
+	var names []string
+	for _, name := range slices.Concat(e.shutdownState.interrupted, e.shutdownState.running) {
+		if quoted := fmt.Sprintf("%q", name); !slices.Contains(names, quoted) {
+			names = append(names, quoted)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	e.Logger.Errf(logger.Yellow, "task: Interrupted tasks: %s\n", strings.Join(names, ", "))
+}
@@ -187,6 +187,159 @@ func TestSignalSentToProcessGroup(t *testing.T) {
 	}
 }
 
+func TestSignalForwardedToChildren(t *testing.T) {
+	task, err := getTaskPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	sut := exec.Command(task, "--", SLEEPIT, "default", "-sleep=10s")
+	sut.Stdout = &out
+	sut.Stderr = &out
+	sut.Dir = "testdata/ignore_signals"
+	sut.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
+
+	if err := sut.Start(); err != nil {
+		t.Fatalf("starting the SUT process: %v", err)
+	}
+
+	ready := false
+	timeout := time.Duration(time.Second)
+	start := time.Now()
+	for time.Since(start) < timeout {
+		if strings.Contains(out.String(), "sleepit: work started\n") {
+			ready = true
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !ready {
+		t.Fatalf("sleepit not ready after %v\n  output:\n%s", timeout, out.String())
+	}
+
+	// Unlike a terminal, a supervisor (such as a CI runner) usually only
+	// signals the Task process itself, so Task must forward it to its children
+	if err := syscall.Kill(sut.Process.Pid, syscall.SIGTERM); err != nil {
+		t.Fatalf("sending TERM signal to the process: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- sut.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		_ = sut.Process.Kill()
+		t.Fatalf("task did not exit after the signal was forwarded\n  output:\n%s", out.String())
+	}
+
+	want := []string{
+		"task: Signal received: \"terminated\"\n",
+		// 143 = 128 + SIGTERM
+		"task: Failed to run task \"default\": exit status 143\n",
+		"task: Interrupted tasks: \"default\"\n",
+	}
+	gotLines := strings.SplitAfter(out.String(), "\n")
+	if notFound := listDifference(want, gotLines); len(notFound) > 0 {
+		t.Errorf("\nwanted but not found:\n%v\noutput:\n%v", notFound, gotLines)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	task, err := getTaskPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		dir     string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		// The second step of .taskrc.yml kills the child during its cleanup
+		"shutdown escalation kills the child after its delay": {
+			dir:  "testdata/shutdown_escalation",
+			args: []string{task, "--", SLEEPIT, "handle", "-sleep=10s", "-cleanup=10s"},
+			want: []string{
+				"task: Signal received: \"interrupt\"\n",
+				"sleepit: got signal=interrupt count=1\n",
+				"sleepit: cleanup started\n",
+				// 137 = 128 + SIGKILL
+				"task: Failed to run task \"default\": exit status 137\n",
+			},
+			notWant: []string{
+				"sleepit: cleanup done\n",
+			},
+		},
+		// The deferred command is not signalled by the shutdown sequence, but
+		// is stopped once the defer timeout expired
+		"defer timeout stops the deferred command": {
+			dir:  "testdata/defer_timeout",
+			args: []string{task, "--defer-timeout=500ms", "--", SLEEPIT},
+			want: []string{
+				"task: Signal received: \"interrupt\"\n",
+				"task: Failed to run task \"default\": exit status 130\n",
+			},
+			notWant: []string{
+				"sleepit: work done\n",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			sut := exec.Command(tc.args[0], tc.args[1:]...)
+			sut.Stdout = &out
+			sut.Stderr = &out
+			sut.Dir = tc.dir
+			sut.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
+
+			if err := sut.Start(); err != nil {
+				t.Fatalf("starting the SUT process: %v", err)
+			}
+
+			ready := false
+			timeout := time.Duration(time.Second)
+			start := time.Now()
+			for time.Since(start) < timeout {
+				if strings.Contains(out.String(), "sleepit: work started\n") {
+					ready = true
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if !ready {
+				t.Fatalf("sleepit not ready after %v\n  output:\n%s", timeout, out.String())
+			}
+
+			if err := syscall.Kill(-sut.Process.Pid, syscall.SIGINT); err != nil {
+				t.Fatalf("sending INT signal to the process group: %v", err)
+			}
+
+			// Without the escalation or the timeout, the child would only exit
+			// once its 10 seconds of work or cleanup are done
+			done := make(chan error, 1)
+			go func() { done <- sut.Wait() }()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				_ = syscall.Kill(-sut.Process.Pid, syscall.SIGKILL)
+				t.Fatalf("task did not exit in time\n  output:\n%s", out.String())
+			}
+
+			gotLines := strings.SplitAfter(out.String(), "\n")
+			if notFound := listDifference(tc.want, gotLines); len(notFound) > 0 {
+				t.Errorf("\nwanted but not found:\n%v\noutput:\n%v", notFound, gotLines)
+			}
+			if found := listIntersection(tc.notWant, gotLines); len(found) > 0 {
+				t.Errorf("\nunwanted but found:\n%v\noutput:\n%v", found, gotLines)
+			}
+		})
+	}
+}
+
 func getTaskPath() (string, error) {
 	if info, err := os.Stat("./bin/taskr"); err == nil {
 		return info.Name(), nil
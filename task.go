@@ -1,12 +1,14 @@
 package task
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
-	"runtime"
 	"slices"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	"mvdan.cc/sh/v3/interp"
@@ -14,6 +16,7 @@ import (
 	"github.com/vikbert/taskr/v3/errors"
 	"github.com/vikbert/taskr/v3/internal/env"
 	"github.com/vikbert/taskr/v3/internal/execext"
+	"github.com/vikbert/taskr/v3/internal/filepathext"
 	"github.com/vikbert/taskr/v3/internal/fingerprint"
 	"github.com/vikbert/taskr/v3/internal/logger"
 	"github.com/vikbert/taskr/v3/internal/output"
@@ -62,6 +65,7 @@ func (e *Executor) Run(ctx context.Context, calls ...*Call) error {
 	}
 
 	if e.Summary {
+		summary.PrintProfile(e.Logger, e.Profile)
 		for i, c := range calls {
 			compiledTask, err := e.FastCompiledTask(c)
 			if err != nil {
@@ -73,6 +77,10 @@ func (e *Executor) Run(ctx context.Context, calls ...*Call) error {
 		return nil
 	}
 
+	if e.ExplainVars {
+		return e.ExplainTaskVars(calls...)
+	}
+
 	regularCalls, watchCalls, err := e.splitRegularAndWatchCalls(calls...)
 	if err != nil {
 		return err
@@ -82,16 +90,21 @@ func (e *Executor) Run(ctx context.Context, calls ...*Call) error {
 	if e.Failfast {
 		g, ctx = errgroup.WithContext(ctx)
 	}
+	if e.Parallel {
+		regularCalls = parallelCalls(regularCalls)
+	}
 	for _, c := range regularCalls {
 		if e.Parallel {
 			g.Go(func() error { return e.RunTask(ctx, c) })
 		} else {
 			if err := e.RunTask(ctx, c); err != nil {
+				e.printInterruptedTasks()
 				return err
 			}
 		}
 	}
 	if err := g.Wait(); err != nil {
+		e.printInterruptedTasks()
 		return err
 	}
 
@@ -118,6 +131,21 @@ func (e *Executor) splitRegularAndWatchCalls(calls ...*Call) (regularCalls []*Ca
 	return regularCalls, watchCalls, err
 }
 
+// parallelCalls returns copies of the given calls that are marked as running
+// in parallel, if there are several of them
+func parallelCalls(calls []*Call) []*Call {
+	if len(calls) < 2 {
+		return calls
+	}
+	parallel := make([]*Call, len(calls))
+	for i, c := range calls {
+		call := *c
+		call.Parallel = true
+		parallel[i] = &call
+	}
+	return parallel
+}
+
 // RunTask runs a task by its name
 func (e *Executor) RunTask(ctx context.Context, call *Call) error {
 	t, err := e.FastCompiledTask(call)
@@ -129,6 +157,10 @@ func (e *Executor) RunTask(ctx context.Context, call *Call) error {
 		return nil
 	}
 
+	if err := e.isTaskRequiredProfileSet(t); err != nil {
+		return err
+	}
+
 	if err := e.areTaskRequiredVarsSet(t); err != nil {
 		return err
 	}
@@ -152,9 +184,10 @@ func (e *Executor) RunTask(ctx context.Context, call *Call) error {
 	release := e.acquireConcurrencyLimit()
 	defer release()
 
-	if err = e.startExecution(ctx, t, func(ctx context.Context) error {
+	e.shutdownState.taskStarted(t.Name())
+	if err = e.startExecution(ctx, t, func(ctx context.Context) (err error) {
 		e.Logger.VerboseErrf(logger.Magenta, "task: %q started\n", call.Task)
-		if err := e.runDeps(ctx, t); err != nil {
+		if err := e.runDeps(ctx, t, call); err != nil {
 			return err
 		}
 
@@ -208,15 +241,42 @@ func (e *Executor) RunTask(ctx context.Context, call *Call) error {
 			e.Logger.Errf(logger.Red, "task: cannot make directory %q: %v\n", t.Dir, err)
 		}
 
+		if len(t.Uses) > 0 && (e.Dry || e.Verbose) {
+			e.Logger.Errf(logger.Cyan, "task: [%s] uses resources: %s\n", t.Name(), strings.Join(t.Uses, ", "))
+		}
+		resources, err := e.acquireResources(ctx, t.Uses)
+		if err != nil {
+			return err
+		}
+		defer e.releaseResources(resources)
+
+		if e.OutputStyle.Durations {
+			start := time.Now()
+			defer func() { e.printDuration(t, time.Since(start), err) }()
+		}
+
+		log, err := e.openTaskLog(t, call)
+		if err != nil {
+			return err
+		}
+		defer func() { e.closeTaskLog(t, log, err) }()
+
+		section := e.openTaskSection(t, call)
+		defer func() { e.closeTaskSection(section, err) }()
+
 		var deferredExitCode uint8
 
 		for i := range t.Cmds {
 			if t.Cmds[i].Defer {
-				defer e.runDeferred(t, call, i, t.Vars, &deferredExitCode)
+				defer func() {
+					if deferredErr := e.runDeferred(t, call, i, t.Vars, &deferredExitCode, section, log, resources); deferredErr != nil && err == nil {
+						err = deferredErr
+					}
+				}()
 				continue
 			}
 
-			if err := e.runCommand(ctx, t, call, i); err != nil {
+			if err := e.runCommand(ctx, t, call, i, section, log, resources); err != nil {
 				if err2 := e.statusOnError(t); err2 != nil {
 					e.Logger.VerboseErrf(logger.Yellow, "task: error cleaning status on error: %v\n", err2)
 				}
@@ -236,12 +296,62 @@ func (e *Executor) RunTask(ctx context.Context, call *Call) error {
 		e.Logger.VerboseErrf(logger.Magenta, "task: %q finished\n", call.Task)
 		return nil
 	}); err != nil {
-		return &errors.TaskRunError{TaskName: t.Name(), Err: err}
+		e.shutdownState.taskFinished(t.Name(), err)
+		return e.taskRunError(t, err)
 	}
 
+	e.shutdownState.taskFinished(t.Name(), nil)
 	return nil
 }
 
+// taskRunError wraps the error of the given task and reports it to the CI
+// provider. Failures of preconditions and of the tasks it calls are reported
+// where they happen instead.
+func (e *Executor) taskRunError(t *ast.Task, err error) error {
+	runErr := &errors.TaskRunError{TaskName: t.Name(), Err: err}
+	if _, ok := output.AsAnnotator(e.Output); !ok {
+		return runErr
+	}
+	runErr.Annotated = true
+
+	var nested *errors.TaskRunError
+	if errors.As(err, &nested) || errors.Is(err, ErrPreconditionFailed) || errors.Is(err, context.Canceled) {
+		return runErr
+	}
+	e.annotate(output.Annotation{
+		Title:    fmt.Sprintf("Task '%s' failed", t.Name()),
+		Message:  err.Error(),
+		Location: t.Location,
+	})
+	return runErr
+}
+
+// annotate reports the given failure to the CI provider, if the output style
+// supports it
+func (e *Executor) annotate(a output.Annotation) {
+	annotator, ok := output.AsAnnotator(e.Output)
+	if !ok {
+		return
+	}
+	if err := annotator.Annotate(e.Stdout, a); err != nil {
+		e.Logger.VerboseErrf(logger.Yellow, "task: unable to write annotation: %v\n", err)
+	}
+}
+
+// printDuration prints how long the commands of the given task took
+func (e *Executor) printDuration(t *ast.Task, d time.Duration, err error) {
+	if d >= time.Second {
+		d = d.Round(100 * time.Millisecond)
+	} else {
+		d = d.Round(time.Millisecond)
+	}
+	if err != nil {
+		e.Logger.Errf(logger.Magenta, "task: [%s] failed after %s\n", t.Name(), d)
+		return
+	}
+	e.Logger.Errf(logger.Magenta, "task: [%s] finished in %s\n", t.Name(), d)
+}
+
 func (e *Executor) mkdir(t *ast.Task) error {
 	if t.Dir == "" {
 		return nil
@@ -259,7 +369,7 @@ func (e *Executor) mkdir(t *ast.Task) error {
 	return nil
 }
 
-func (e *Executor) runDeps(ctx context.Context, t *ast.Task) error {
+func (e *Executor) runDeps(ctx context.Context, t *ast.Task, call *Call) error {
 	g := &errgroup.Group{}
 	if e.Failfast || t.Failfast {
 		g, ctx = errgroup.WithContext(ctx)
@@ -270,7 +380,7 @@ func (e *Executor) runDeps(ctx context.Context, t *ast.Task) error {
 
 	for _, d := range t.Deps {
 		g.Go(func() error {
-			err := e.RunTask(ctx, &Call{Task: d.Task, Vars: d.Vars, Silent: d.Silent, Indirect: true})
+			err := e.RunTask(ctx, &Call{Task: d.Task, Vars: d.Vars, Silent: d.Silent, Indirect: true, Parallel: call.Parallel || len(t.Deps) > 1})
 			if err != nil {
 				return err
 			}
@@ -281,12 +391,21 @@ func (e *Executor) runDeps(ctx context.Context, t *ast.Task) error {
 	return g.Wait()
 }
 
-func (e *Executor) runDeferred(t *ast.Task, call *Call, i int, vars *ast.Vars, deferredExitCode *uint8) {
+// runDeferred runs the deferred command of the given task. The errors of the
+// command are ignored, but not those of its templates.
+func (e *Executor) runDeferred(t *ast.Task, call *Call, i int, vars *ast.Vars, deferredExitCode *uint8, section *taskSection, log *taskLog, resources *resourceClaim) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Deferred commands are not stopped by the shutdown sequence, so they are
+	// given their own deadline instead
+	if e.DeferTimeout > 0 && e.shutdownState.inProgress() {
+		ctx, cancel = context.WithTimeout(ctx, e.DeferTimeout)
+		defer cancel()
+	}
+
 	cmd := t.Cmds[i]
-	cache := &templater.Cache{Vars: vars}
+	cache := &templater.Cache{Vars: vars, Strict: e.Strict || t.Strict, Dir: t.Dir}
 	extra := map[string]any{}
 
 	if deferredExitCode != nil && *deferredExitCode > 0 {
@@ -296,21 +415,34 @@ func (e *Executor) runDeferred(t *ast.Task, call *Call, i int, vars *ast.Vars, d
 	cmd.Cmd = templater.ReplaceWithExtra(cmd.Cmd, cache, extra)
 	cmd.Task = templater.ReplaceWithExtra(cmd.Task, cache, extra)
 	cmd.Vars = templater.ReplaceVarsWithExtra(cmd.Vars, cache, extra)
+	if err := cache.Err(); err != nil {
+		return withTaskLocation(err, t)
+	}
 
-	if err := e.runCommand(ctx, t, call, i); err != nil {
+	if err := e.runCommand(ctx, t, call, i, section, log, resources); err != nil {
 		e.Logger.VerboseErrf(logger.Yellow, "task: ignored error in deferred cmd: %s\n", err.Error())
 	}
+	return nil
 }
 
-func (e *Executor) runCommand(ctx context.Context, t *ast.Task, call *Call, i int) error {
+func (e *Executor) runCommand(ctx context.Context, t *ast.Task, call *Call, i int, section *taskSection, log *taskLog, resources *resourceClaim) error {
 	cmd := t.Cmds[i]
 
 	switch {
 	case cmd.Task != "":
 		reacquire := e.releaseConcurrencyLimit()
 		defer reacquire()
+		resumeResources := e.suspendResources(resources)
 
-		err := e.RunTask(ctx, &Call{Task: cmd.Task, Vars: cmd.Vars, Silent: cmd.Silent, Indirect: true})
+		err := e.RunTask(ctx, &Call{Task: cmd.Task, Vars: cmd.Vars, Silent: cmd.Silent, Indirect: true, Parallel: call.Parallel})
+		// The task can't go on without its resources, even if the error of
+		// the called task is ignored
+		if resumeErr := resumeResources(ctx); resumeErr != nil {
+			if err != nil {
+				return err
+			}
+			return resumeErr
+		}
 		var exitCode interp.ExitStatus
 		if errors.As(err, &exitCode) && cmd.IgnoreError {
 			e.Logger.VerboseErrf(logger.Yellow, "task: [%s] task error ignored: %v\n", t.Name(), err)
@@ -324,7 +456,8 @@ func (e *Executor) runCommand(ctx context.Context, t *ast.Task, call *Call, i in
 		}
 
 		if e.Verbose || (!call.Silent && !cmd.Silent && !t.Silent && !e.Taskfile.Silent && !e.Silent) {
-			e.Logger.Errf(logger.Green, "task: [%s] %s\n", t.Name(), cmd.Cmd)
+			_, stdErr := section.writers(e.Stdout, e.Stderr)
+			e.Logger.FOutf(stdErr, logger.Green, "task: [%s] %s\n", t.Name(), e.Compiler.Redact(cmd.Cmd))
 		}
 
 		if e.Dry {
@@ -336,24 +469,54 @@ func (e *Executor) runCommand(ctx context.Context, t *ast.Task, call *Call, i in
 			outputWrapper = output.Interleaved{}
 		}
 		vars, err := e.Compiler.FastGetVariables(t, call)
-		outputTemplater := &templater.Cache{Vars: vars}
+		outputTemplater := &templater.Cache{Vars: vars, Strict: e.Strict || t.Strict, Dir: t.Dir}
 		if err != nil {
 			return fmt.Errorf("task: failed to get variables: %w", err)
 		}
-		stdOut, stdErr, closer := outputWrapper.WrapWriter(e.Stdout, e.Stderr, t.Prefix, outputTemplater)
-
-		err = execext.RunCommand(ctx, &execext.RunCommandOptions{
-			Command:   cmd.Cmd,
-			Dir:       t.Dir,
-			Env:       env.Get(t),
-			PosixOpts: slicesext.UniqueJoin(e.Taskfile.Set, t.Set, cmd.Set),
-			BashOpts:  slicesext.UniqueJoin(e.Taskfile.Shopt, t.Shopt, cmd.Shopt),
-			Stdin:     e.Stdin,
-			Stdout:    stdOut,
-			Stderr:    stdErr,
-		})
-		if closeErr := closer(err); closeErr != nil {
-			e.Logger.Errf(logger.Red, "task: unable to close writer: %v\n", closeErr)
+
+		processes := e.processes
+		if cmd.Defer {
+			processes = e.deferredProcesses
+		}
+
+		var container *execext.ContainerOptions
+		if t.Container != nil {
+			container = &execext.ContainerOptions{
+				Runtime: e.ContainerRuntime,
+				Image:   t.Container.Image,
+				Volumes: t.Container.Volumes,
+				Workdir: t.Container.Workdir,
+				Env:     env.GetContainer(t),
+			}
+		}
+
+		opts := &execext.RunCommandOptions{
+			Command:     cmd.Cmd,
+			Dir:         t.Dir,
+			Env:         env.Get(t),
+			PosixOpts:   slicesext.UniqueJoin(e.Taskfile.Set, t.Set, cmd.Set),
+			BashOpts:    slicesext.UniqueJoin(e.Taskfile.Shopt, t.Shopt, cmd.Shopt),
+			Stdin:       e.Stdin,
+			Processes:   processes,
+			Container:   container,
+			Interpreter: cmp.Or(cmd.Interpreter, t.Interpreter),
+			ScriptDir:   filepathext.SmartJoin(e.TempDir.Fingerprint, "scripts"),
+		}
+		hosts, err := e.hostsFor(t, cmd)
+		if err != nil {
+			return err
+		}
+		if len(hosts) > 0 {
+			err = e.runCommandOnHosts(ctx, t, opts, hosts, outputWrapper, outputTemplater, section, log)
+		} else {
+			var closer output.CloseFunc
+			stdOut, stdErr := section.writers(e.Stdout, e.Stderr)
+			opts.Stdout, opts.Stderr, closer = outputWrapper.WrapWriter(stdOut, stdErr, t.Prefix, outputTemplater)
+			opts.Stdout, opts.Stderr = log.tee(opts.Stdout, opts.Stderr)
+			err = execext.RunCommand(ctx, opts)
+			if closeErr := closer(err); closeErr != nil {
+				e.Logger.Errf(logger.Red, "task: unable to close writer: %v\n", closeErr)
+			}
 		}
 		var exitCode interp.ExitStatus
 		if errors.As(err, &exitCode) && cmd.IgnoreError {
@@ -529,13 +692,5 @@ func FilterOutInternal(task *ast.Task) bool {
 }
 
 func shouldRunOnCurrentPlatform(platforms []*ast.Platform) bool {
-	if len(platforms) == 0 {
-		return true
-	}
-	for _, p := range platforms {
-		if (p.OS == "" || p.OS == runtime.GOOS) && (p.Arch == "" || p.Arch == runtime.GOARCH) {
-			return true
-		}
-	}
-	return false
+	return ast.MatchesCurrentPlatform(platforms)
 }
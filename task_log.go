@@ -0,0 +1,220 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vikbert/taskr/v3/errors"
+	"github.com/vikbert/taskr/v3/internal/filepathext"
+	"github.com/vikbert/taskr/v3/internal/logger"
+	"github.com/vikbert/taskr/v3/internal/output"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// taskLog is the file that the output of a task invocation is saved to. The
+// commands of the task write to it concurrently when they run on several
+// hosts, so writes are serialized.
+type taskLog struct {
+	path  string
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// openTaskLog creates the log file of the given task invocation, or returns
+// nil if its output is not logged. Interactive tasks are never logged since
+// teeing their output would hide the terminal from them.
+func (e *Executor) openTaskLog(t *ast.Task, call *Call) (*taskLog, error) {
+	if e.Dry || t.Interactive || len(t.Cmds) == 0 {
+		return nil, nil
+	}
+
+	dir := e.LogDir
+	if t.Log != nil {
+		if !t.Log.Enabled {
+			return nil, nil
+		}
+		switch {
+		case t.Log.Dir != "":
+			dir = filepathext.SmartJoin(t.Dir, t.Log.Dir)
+		case dir == "":
+			dir = filepath.Join(e.TempDir.Fingerprint, "logs")
+		}
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	// Invocations of the same task are told apart by the variables they are
+	// called with, e.g. the values of a for loop or a matrix. The matches of
+	// wildcard tasks are already part of their full name.
+	parts := []string{cmp.Or(t.FullName, t.Task)}
+	if call.Vars != nil {
+		for name, v := range call.Vars.All() {
+			if name == "MATCH" {
+				continue
+			}
+			parts = append(parts, fmt.Sprint(v.Value))
+		}
+	}
+	parts = append(parts, time.Now().Format("20060102-150405.000"))
+	name := logFileNameReplacer.ReplaceAllString(strings.Join(parts, "-"), "_")
+
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, name+".log")
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.log", name, i))
+		}
+		// The output may contain secrets, so only the user can read it
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &taskLog{path: path, file: file}, nil
+	}
+}
+
+var logFileNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// tee returns writers that also write to the log, without the ANSI escape
+// sequences. It returns the given writers as is if the task is not logged.
+func (l *taskLog) tee(stdOut, stdErr io.Writer) (io.Writer, io.Writer) {
+	if l == nil {
+		return stdOut, stdErr
+	}
+	// Each writer needs its own stripper since a sequence can be split
+	// across writes
+	return io.MultiWriter(stdOut, &ansiStripper{writer: l}),
+		io.MultiWriter(stdErr, &ansiStripper{writer: l})
+}
+
+func (l *taskLog) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.file.Write(p)
+}
+
+// closeTaskLog closes the log file and reports where it is when the task
+// failed
+func (e *Executor) closeTaskLog(t *ast.Task, l *taskLog, err error) {
+	if l == nil {
+		return
+	}
+	if closeErr := l.file.Close(); closeErr != nil {
+		e.Logger.Errf(logger.Red, "task: unable to close log file: %v\n", closeErr)
+	}
+	if err != nil {
+		e.Logger.Errf(logger.Magenta, "task: [%s] output saved to %s\n", t.Name(), filepathext.TryAbsToRel(l.path))
+		return
+	}
+	e.Logger.VerboseErrf(logger.Magenta, "task: [%s] output saved to %s\n", t.Name(), filepathext.TryAbsToRel(l.path))
+}
+
+// ansiStripper removes the ANSI escape sequences, such as colors, from what
+// is written to it
+type ansiStripper struct {
+	writer io.Writer
+	state  int
+}
+
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+	ansiOSC
+	ansiOSCEscape
+)
+
+func (s *ansiStripper) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, c := range p {
+		switch s.state {
+		case ansiText:
+			if c == 0x1b {
+				s.state = ansiEscape
+				continue
+			}
+			out = append(out, c)
+		case ansiEscape:
+			switch c {
+			case '[':
+				s.state = ansiCSI
+			case ']':
+				s.state = ansiOSC
+			default:
+				s.state = ansiText
+			}
+		case ansiCSI:
+			// Parameters and intermediate bytes until the final byte
+			if c >= 0x40 && c <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiOSC:
+			// Terminated by BEL or by ESC \
+			switch c {
+			case 0x07:
+				s.state = ansiText
+			case 0x1b:
+				s.state = ansiOSCEscape
+			}
+		case ansiOSCEscape:
+			s.state = ansiText
+		}
+	}
+	if _, err := s.writer.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// taskSection is the section of the CI provider that the output of a task
+// invocation is grouped in, see [output.Sectioner]
+type taskSection struct {
+	stdOut io.Writer
+	stdErr io.Writer
+	close  output.CloseFunc
+}
+
+// openTaskSection opens the section of the given task invocation, or returns
+// nil if the output style has no sections. Like their logs, the output of
+// interactive tasks is never grouped.
+func (e *Executor) openTaskSection(t *ast.Task, call *Call) *taskSection {
+	sectioner, ok := output.AsSectioner(e.Output)
+	if !ok || t.Interactive || len(t.Cmds) == 0 {
+		return nil
+	}
+	stdOut, stdErr, closer := sectioner.Section(e.Stdout, e.Stderr, t.Prefix, call.Parallel)
+	return &taskSection{stdOut: stdOut, stdErr: stdErr, close: closer}
+}
+
+// writers returns the writers that the commands of the task write to. It
+// returns the given writers as is if the task has no section.
+func (s *taskSection) writers(stdOut, stdErr io.Writer) (io.Writer, io.Writer) {
+	if s == nil {
+		return stdOut, stdErr
+	}
+	return s.stdOut, s.stdErr
+}
+
+// closeTaskSection writes the section once the task finished
+func (e *Executor) closeTaskSection(s *taskSection, err error) {
+	if s == nil {
+		return
+	}
+	if closeErr := s.close(err); closeErr != nil {
+		e.Logger.Errf(logger.Red, "task: unable to close writer: %v\n", closeErr)
+	}
+}
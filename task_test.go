@@ -2,6 +2,7 @@ package task_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
@@ -11,6 +12,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -18,6 +20,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,6 +33,7 @@ import (
 	"github.com/vikbert/taskr/v3/errors"
 	"github.com/vikbert/taskr/v3/experiments"
 	"github.com/vikbert/taskr/v3/internal/filepathext"
+	"github.com/vikbert/taskr/v3/taskfile"
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 )
 
@@ -37,6 +41,18 @@ func init() {
 	_ = os.Setenv("NO_COLOR", "1")
 }
 
+func TestMain(m *testing.M) {
+	// Keep the parsed Taskfiles of the tests out of the user's cache directory
+	cacheDir, err := os.MkdirTemp("", "task-cache")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv("XDG_CACHE_HOME", cacheDir)
+	code := m.Run()
+	_ = os.RemoveAll(cacheDir)
+	os.Exit(code)
+}
+
 type (
 	TestOption interface {
 		ExecutorTestOption
@@ -880,6 +896,164 @@ func TestIncludesRemote(t *testing.T) {
 	}
 }
 
+func TestIncludesRemoteLock(t *testing.T) {
+	enableExperimentForTest(t, &experiments.RemoteTaskfiles, 1)
+
+	const dir = "testdata/includes_remote_lock"
+	lockfilePath := filepath.Join(dir, taskfile.LockfileName)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(filepath.Join(dir, ".task"))
+		_ = os.Remove(lockfilePath)
+	})
+
+	var content atomic.Value
+	content.Store("version: '3'\ntasks:\n  default: echo first\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/yaml")
+		_, _ = io.WriteString(w, content.Load().(string))
+	}))
+	defer srv.Close()
+	t.Setenv("REMOTE_URL", srv.URL+"/Taskfile.yml")
+
+	newExecutor := func(opts ...task.ExecutorOption) *task.Executor {
+		var buff SyncBuffer
+		return task.NewExecutor(append([]task.ExecutorOption{
+			task.WithDir(dir),
+			task.WithStdout(&buff),
+			task.WithStderr(&buff),
+			task.WithInsecure(true),
+			task.WithTimeout(time.Minute),
+			task.WithAssumeYes(true),
+		}, opts...)...)
+	}
+
+	// Generate the lockfile
+	require.NoError(t, newExecutor(task.WithLock(true)).Setup())
+	lockfile, err := taskfile.ReadLockfile(lockfilePath)
+	require.NoError(t, err)
+	locked, ok := lockfile.Get(srv.URL + "/Taskfile.yml")
+	require.True(t, ok)
+	assert.Equal(t, srv.URL+"/Taskfile.yml", locked.URL)
+	assert.NotEmpty(t, locked.Checksum)
+
+	// The locked Taskfile is accepted
+	require.NoError(t, newExecutor(task.WithDownload(true)).Setup())
+
+	// A Taskfile that changed since it was locked is rejected
+	content.Store("version: '3'\ntasks:\n  default: echo tampered\n")
+	err = newExecutor(task.WithDownload(true)).Setup()
+	var checksumErr *errors.TaskfileDoesNotMatchChecksum
+	require.ErrorAs(t, err, &checksumErr)
+	assert.Equal(t, locked.Checksum, checksumErr.ExpectedChecksum)
+
+	// A Taskfile that is missing from the lockfile is rejected
+	require.NoError(t, taskfile.NewLockfile().Write(lockfilePath))
+	err = newExecutor().Setup()
+	var notLockedErr *errors.TaskfileNotLockedError
+	require.ErrorAs(t, err, &notLockedErr)
+
+	// Updating the lockfile accepts the new Taskfile
+	require.NoError(t, newExecutor(task.WithLock(true)).Setup())
+	require.NoError(t, newExecutor().Setup())
+}
+
+func TestRemoteCache(t *testing.T) {
+	enableExperimentForTest(t, &experiments.RemoteTaskfiles, 1)
+
+	const dir = "testdata/remote_cache"
+	t.Cleanup(func() {
+		_ = os.RemoveAll(filepath.Join(dir, ".task"))
+	})
+
+	var content atomic.Value
+	content.Store("version: '3'\ntasks:\n  default: echo first\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/yaml")
+		_, _ = io.WriteString(w, content.Load().(string))
+	}))
+	defer srv.Close()
+	source := srv.URL + "/Taskfile.yml"
+	t.Setenv("REMOTE_URL", source)
+
+	newExecutor := func(buff *SyncBuffer) *task.Executor {
+		return task.NewExecutor(
+			task.WithDir(dir),
+			task.WithStdout(buff),
+			task.WithStderr(buff),
+			task.WithInsecure(true),
+			task.WithTimeout(time.Minute),
+			task.WithAssumeYes(true),
+			task.WithCacheExpiryDuration(time.Hour),
+		)
+	}
+
+	var buff SyncBuffer
+	require.NoError(t, newExecutor(&buff).ListRemotes())
+	assert.Contains(t, buff.buf.String(), "No remote Taskfiles are cached")
+
+	buff = SyncBuffer{}
+	require.NoError(t, newExecutor(&buff).UpdateRemotes("remote"))
+	assert.Contains(t, buff.buf.String(), fmt.Sprintf("Updated remote Taskfile %q", source))
+
+	buff = SyncBuffer{}
+	require.NoError(t, newExecutor(&buff).ListRemotes())
+	assert.Contains(t, buff.buf.String(), source)
+
+	buff = SyncBuffer{}
+	content.Store("version: '3'\ntasks:\n  default: echo second\n")
+	require.NoError(t, newExecutor(&buff).DiffRemotes(t.Context()))
+	assert.Contains(t, buff.buf.String(), "-  default: echo first\n+  default: echo second\n")
+
+	buff = SyncBuffer{}
+	err := newExecutor(&buff).UpdateRemotes("other", "missing")
+	require.EqualError(t, err, "task: No remote Taskfile is included under the namespace(s) other, missing")
+	assert.NotContains(t, buff.buf.String(), "Remote Taskfiles are up to date")
+
+	buff = SyncBuffer{}
+	require.NoError(t, newExecutor(&buff).UpdateRemotes())
+	assert.Contains(t, buff.buf.String(), fmt.Sprintf("Updated remote Taskfile %q", source))
+
+	buff = SyncBuffer{}
+	require.NoError(t, newExecutor(&buff).PruneRemotes())
+	assert.NotContains(t, buff.buf.String(), "Pruned")
+
+	buff = SyncBuffer{}
+	t.Setenv("REMOTE_URL", srv.URL+"/other/Taskfile.yml")
+	require.NoError(t, newExecutor(&buff).PruneRemotes())
+	assert.Contains(t, buff.buf.String(), fmt.Sprintf("Pruned remote Taskfile %q", source))
+}
+
+func TestUpdateRemotesLocalTaskfile(t *testing.T) {
+	enableExperimentForTest(t, &experiments.RemoteTaskfiles, 1)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte("version: '3'\ntasks:\n  default: echo local\n"), 0o644))
+	tempDir := task.TempDir{
+		Remote:      filepath.Join(dir, ".task"),
+		Fingerprint: filepath.Join(dir, ".task"),
+		Taskfile:    t.TempDir(),
+	}
+	newExecutor := func(buff *SyncBuffer) *task.Executor {
+		return task.NewExecutor(
+			task.WithDir(dir),
+			task.WithTempDir(tempDir),
+			task.WithStdout(buff),
+			task.WithStderr(buff),
+		)
+	}
+
+	// A normal run caches the parsed Taskfile
+	var buff SyncBuffer
+	e := newExecutor(&buff)
+	require.NoError(t, e.Setup())
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+
+	buff = SyncBuffer{}
+	err := newExecutor(&buff).UpdateRemotes("lib")
+	require.EqualError(t, err, "task: No remote Taskfile is included under the namespace(s) lib")
+	assert.NotContains(t, buff.buf.String(), "Remote Taskfiles are up to date")
+}
+
 func TestIncludeCycle(t *testing.T) {
 	t.Parallel()
 
@@ -1235,6 +1409,138 @@ func TestIncludesFlatten(t *testing.T) {
 	}
 }
 
+func TestIncludesRequires(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/includes_requires"
+	tests := []struct {
+		name           string
+		taskfile       string
+		task           string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{name: "required vars and defaults", taskfile: "Taskfile.yml", task: "lib:deploy", expectedOutput: "helper\ndeploy prod eu\n"},
+		{name: "not exported", taskfile: "Taskfile.yml", task: "lib:helper", expectedErr: `task: Task "lib:helper" is internal`},
+		{name: "missing required vars", taskfile: "Taskfile.missing.yml", expectedErr: "is missing required variables: ENV (allowed values: [dev prod])"},
+		{name: "not allowed vars", taskfile: "Taskfile.invalid.yml", expectedErr: "ENV has an invalid value : 'staging' (allowed values : [dev prod])"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithEntrypoint(dir+"/"+test.taskfile),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+			)
+			err := e.Setup()
+			if err == nil && test.task != "" {
+				err = e.Run(t.Context(), &task.Call{Task: test.task})
+			}
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+}
+
+func TestIncludesConditional(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/includes_conditional"
+	tests := []struct {
+		name           string
+		taskfile       string
+		task           string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{name: "condition is true", taskfile: "Taskfile.yml", task: "ci:build", expectedOutput: "ci\n"},
+		{name: "condition is false", taskfile: "Taskfile.yml", task: "local:build", expectedErr: `task: Task "local:build" does not exist`},
+		{name: "other platform", taskfile: "Taskfile.yml", task: "legacy:build", expectedErr: `task: Task "legacy:build" does not exist`},
+		{name: "invalid condition", taskfile: "Taskfile.invalid.yml", expectedErr: `include "ci": if condition must be true or false, got "maybe"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithEntrypoint(dir+"/"+test.taskfile),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+			)
+			err := e.Setup()
+			if err == nil && test.task != "" {
+				err = e.Run(t.Context(), &task.Call{Task: test.task})
+			}
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+}
+
+func TestIncludesGlob(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/includes_glob"
+	tests := []struct {
+		name           string
+		taskfile       string
+		task           string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{name: "first match", taskfile: "Taskfile.yml", task: "svc:api:build", expectedOutput: "api\n"},
+		{name: "second match", taskfile: "Taskfile.yml", task: "svc:web:build", expectedOutput: "web\n"},
+		{name: "directory without Taskfile", taskfile: "Taskfile.yml", task: "svc:docs:build", expectedErr: `task: Task "svc:docs:build" does not exist`},
+		{name: "no matches", taskfile: "Taskfile.missing.yml", expectedErr: "task: No Taskfile found at"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithEntrypoint(dir+"/"+test.taskfile),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+			)
+			err := e.Setup()
+			if err == nil && test.task != "" {
+				err = e.Run(t.Context(), &task.Call{Task: test.task})
+			}
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+}
+
 func TestIncludesInterpolation(t *testing.T) { // nolint:paralleltest // cannot run in parallel
 	const dir = "testdata/includes_interpolation"
 	tests := []struct {
@@ -1639,20 +1945,64 @@ func TestDotenvShouldIncludeAllEnvFiles(t *testing.T) {
 	})
 }
 
-func TestDotenvShouldErrorWhenIncludingDependantDotenvs(t *testing.T) {
+func TestDotenvIncludedTaskfilesAreScoped(t *testing.T) {
 	t.Parallel()
 
-	var buff bytes.Buffer
-	e := task.NewExecutor(
-		task.WithDir("testdata/dotenv/error_included_envs"),
-		task.WithSummary(true),
-		task.WithStdout(&buff),
-		task.WithStderr(&buff),
-	)
+	tests := []struct {
+		task           string
+		expectedOutput string
+	}{
+		{task: "default", expectedOutput: "INCLUDE1=''\n"},
+		{task: "include1:default", expectedOutput: "INCLUDE1='from_include1'\n"},
+	}
 
-	err := e.Setup()
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "move the dotenv")
+	for _, test := range tests {
+		t.Run(test.task, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir("testdata/dotenv/included_envs"),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+			)
+			require.NoError(t, e.Setup())
+			require.NoError(t, e.Run(t.Context(), &task.Call{Task: test.task}))
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+}
+
+func TestDotenvEnvironment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		environment    string
+		expectedOutput string
+	}{
+		{environment: "", expectedOutput: "http://localhost/api base\n"},
+		{environment: "prod", expectedOutput: "https://example.com/api prod-local\n"},
+		{environment: "staging", expectedOutput: "http://localhost/api base\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.environment, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir("testdata/dotenv/environments"),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+				task.WithEnvironment(test.environment),
+			)
+			require.NoError(t, e.Setup())
+			require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
 }
 
 func TestDotenvShouldAllowMissingEnv(t *testing.T) {
@@ -2121,23 +2471,119 @@ Hello bar
 	assert.Equal(t, expectedOutputOrder, strings.TrimSpace(buff.String()))
 }
 
-func TestErrorCode(t *testing.T) {
+func TestTaskLog(t *testing.T) {
 	t.Parallel()
 
-	const dir = "testdata/error_code"
+	const dir = "testdata/task_log"
+	t.Cleanup(func() {
+		_ = os.RemoveAll(filepath.Join(dir, ".task"))
+		_ = os.RemoveAll(filepath.Join(dir, "logs"))
+	})
+	logDir := t.TempDir()
+
+	run := func(taskName string, opts ...task.ExecutorOption) (string, error) {
+		var buff bytes.Buffer
+		e := task.NewExecutor(append([]task.ExecutorOption{
+			task.WithDir(dir),
+			task.WithStdout(&buff),
+			task.WithStderr(&buff),
+			task.WithSilent(true),
+		}, opts...)...)
+		require.NoError(t, e.Setup())
+		err := e.Run(t.Context(), &task.Call{Task: taskName})
+		return buff.String(), err
+	}
+	logs := func(pattern string) []string {
+		paths, err := filepath.Glob(pattern)
+		require.NoError(t, err)
+		contents := make([]string, len(paths))
+		for i, path := range paths {
+			b, err := os.ReadFile(path)
+			require.NoError(t, err)
+			contents[i] = string(b)
+		}
+		return contents
+	}
+
+	// Each invocation has its own file, named with the values of the loop,
+	// without the ANSI escape sequences
+	_, err := run("default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"building linux\n"}, logs(filepath.Join(dir, ".task", "logs", "build-linux-*.log")))
+	assert.Equal(t, []string{"building darwin\n"}, logs(filepath.Join(dir, ".task", "logs", "build-darwin-*.log")))
+
+	// The logs are only readable by the user
+	if runtime.GOOS != "windows" {
+		files, err := filepath.Glob(filepath.Join(dir, ".task", "logs", "*.log"))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), file)
+		}
+	}
+
+	// The path of the log is reported when the task fails
+	out, err := run("fails")
+	require.Error(t, err)
+	assert.Equal(t, []string{"oops\n"}, logs(filepath.Join(dir, "logs", "fails-*.log")))
+	assert.Regexp(t, `task: \[fails\] output saved to testdata/task_log/logs/fails-[\d.-]+\.log\n`, out)
+
+	// The log directory applies to every task unless it opts out
+	_, err = run("unlogged", task.WithLogDir(logDir))
+	require.NoError(t, err)
+	_, err = run("quiet", task.WithLogDir(logDir))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unlogged\n"}, logs(filepath.Join(logDir, "*.log")))
+}
+
+func TestOutputTimestampsAndDurations(t *testing.T) {
+	t.Parallel()
+
+	var buff bytes.Buffer
+	e := task.NewExecutor(
+		task.WithDir("testdata/output_timestamps"),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(true),
+	)
+	require.NoError(t, e.Setup())
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+
+	stamp := `\[\d{2}:\d{2}:\d{2}\.\d{3}\]`
+	assert.Regexp(t, `^\[default\] `+stamp+` foo\n\[default\] `+stamp+` bar\ntask: \[default\] finished in \d+(\.\d+)?m?s\n$`, buff.String())
+}
+
+func TestCIOutputAnnotations(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/ci_output"
 	tests := []struct {
 		name     string
 		task     string
-		expected int
+		silent   bool
+		expected string
 	}{
 		{
-			name:     "direct task",
-			task:     "direct",
-			expected: 42,
+			name:     "failed command",
+			task:     "fails",
+			silent:   true,
+			expected: "::group::fails\nout\n::endgroup::\n::error file=testdata/ci_output/Taskfile.yml,line=4,col=3,title=Task 'fails' failed::exit status 1\n",
 		}, {
-			name:     "indirect task",
+			name:     "failed called task",
 			task:     "indirect",
-			expected: 42,
+			silent:   true,
+			expected: "::group::fails\nout\n::endgroup::\n::error file=testdata/ci_output/Taskfile.yml,line=4,col=3,title=Task 'fails' failed::exit status 1\n",
+		}, {
+			name:     "failed precondition",
+			task:     "precondition",
+			silent:   true,
+			expected: "task: not ready\n::error file=testdata/ci_output/Taskfile.yml,line=12,col=3,title=Task 'precondition' precondition not met::not ready\n",
+		}, {
+			name:     "one group for all the commands",
+			task:     "steps",
+			expected: "::group::steps\ntask: [steps] echo one\none\ntask: [steps] echo two && exit 1\ntwo\n::endgroup::\n::error file=testdata/ci_output/Taskfile.yml,line=19,col=3,title=Task 'steps' failed::exit status 1\n",
 		},
 	}
 
@@ -2150,32 +2596,114 @@ func TestErrorCode(t *testing.T) {
 				task.WithDir(dir),
 				task.WithStdout(&buff),
 				task.WithStderr(&buff),
-				task.WithSilent(true),
+				task.WithSilent(test.silent),
+				task.WithOutputStyle(ast.Output{Name: "github"}),
 			)
 			require.NoError(t, e.Setup())
 
 			err := e.Run(t.Context(), &task.Call{Task: test.task})
-			require.Error(t, err)
-			taskRunErr, ok := err.(*errors.TaskRunError)
-			assert.True(t, ok, "cannot cast returned error to *task.TaskRunError")
-			assert.Equal(t, test.expected, taskRunErr.TaskExitCode(), "unexpected exit code from task")
+			var taskRunErr *errors.TaskRunError
+			require.ErrorAs(t, err, &taskRunErr)
+			assert.True(t, taskRunErr.Annotated)
+			assert.Equal(t, test.expected, buff.String())
 		})
 	}
 }
 
-func TestEvaluateSymlinksInPaths(t *testing.T) { // nolint:paralleltest // cannot run in parallel
-	const dir = "testdata/evaluate_symlinks_in_paths"
-	var buff bytes.Buffer
-	e := task.NewExecutor(
-		task.WithDir(dir),
-		task.WithStdout(&buff),
-		task.WithStderr(&buff),
-		task.WithSilent(false),
-	)
+func TestCIOutputSections(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/ci_output"
+
+	t.Run("stderr", func(t *testing.T) {
+		t.Parallel()
+
+		var stdOut, stdErr bytes.Buffer
+		e := task.NewExecutor(
+			task.WithDir(dir),
+			task.WithStdout(&stdOut),
+			task.WithStderr(&stdErr),
+			task.WithOutputStyle(ast.Output{Name: "github"}),
+		)
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "one"}))
+		assert.Equal(t, "::group::one\none\none\n::endgroup::\n", stdOut.String())
+		assert.Equal(t, "task: [one] echo one && sleep 0.1 && echo one\n", stdErr.String())
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+
+		var buff SyncBuffer
+		e := task.NewExecutor(
+			task.WithDir(dir),
+			task.WithStdout(&buff),
+			task.WithStderr(&buff),
+			task.WithSilent(true),
+			task.WithOutputStyle(ast.Output{Name: "github"}),
+		)
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "parallel"}))
+		assert.Contains(t, buff.buf.String(), "::group::one\none\none\n::endgroup::\n")
+		assert.Contains(t, buff.buf.String(), "::group::two\ntwo\ntwo\n::endgroup::\n")
+	})
+}
+
+func TestErrorCode(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/error_code"
 	tests := []struct {
 		name     string
 		task     string
-		expected string
+		expected int
+	}{
+		{
+			name:     "direct task",
+			task:     "direct",
+			expected: 42,
+		}, {
+			name:     "indirect task",
+			task:     "indirect",
+			expected: 42,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+			)
+			require.NoError(t, e.Setup())
+
+			err := e.Run(t.Context(), &task.Call{Task: test.task})
+			require.Error(t, err)
+			taskRunErr, ok := err.(*errors.TaskRunError)
+			assert.True(t, ok, "cannot cast returned error to *task.TaskRunError")
+			assert.Equal(t, test.expected, taskRunErr.TaskExitCode(), "unexpected exit code from task")
+		})
+	}
+}
+
+func TestEvaluateSymlinksInPaths(t *testing.T) { // nolint:paralleltest // cannot run in parallel
+	const dir = "testdata/evaluate_symlinks_in_paths"
+	var buff bytes.Buffer
+	e := task.NewExecutor(
+		task.WithDir(dir),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(false),
+	)
+	tests := []struct {
+		name     string
+		task     string
+		expected string
 	}{
 		{
 			name:     "default (1)",
@@ -2423,6 +2951,494 @@ func TestSplitArgs(t *testing.T) {
 	assert.Equal(t, "3\n", buff.String())
 }
 
+func TestTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	var buff bytes.Buffer
+	e := task.NewExecutor(
+		task.WithDir("testdata/template_funcs"),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(true),
+	)
+	require.NoError(t, e.Setup())
+
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+	assert.Equal(t, "1.4.0\ntrue false\napp\ntrue\nversion.txt\n78b591400c56\nfallback\n", buff.String())
+
+	// Relative paths are resolved in the directory of the task
+	buff.Reset()
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "dir"}))
+	assert.Equal(t, "sub name.txt\n", buff.String())
+
+	err := e.Run(t.Context(), &task.Call{Task: "must"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.txt")
+}
+
+func TestTemplateFuncsJsonFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepathext.SmartJoin(dir, "Taskfile.yml"), []byte(`version: '3'
+
+tasks:
+  default:
+    cmds:
+      - echo '{{toJsonFile "out.json" (dict "name" "app")}}'
+      - echo '{{(fromJsonFile "out.json").name}}'
+`), 0o644))
+
+	var buff bytes.Buffer
+	e := task.NewExecutor(
+		task.WithDir(dir),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(true),
+	)
+	require.NoError(t, e.Setup())
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+	assert.Equal(t, "out.json\napp\n", buff.String())
+
+	b, err := os.ReadFile(filepathext.SmartJoin(dir, "out.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"app\"\n}\n", string(b))
+}
+
+func TestTemplateFuncsGit(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// The repository is not the one Task runs in, so the functions have to
+	// run git in the directory of the Taskfile
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=task", "-c", "user.email=task@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	git("init", "--quiet", "--initial-branch", "feature")
+	require.NoError(t, os.WriteFile(filepathext.SmartJoin(dir, "Taskfile.yml"), []byte(`version: '3'
+
+tasks:
+  default:
+    cmds:
+      - echo '{{gitBranch}} {{gitCommit}}'
+
+  must:
+    cmds:
+      - echo '{{mustGitCommit}}'
+`), 0o644))
+	git("add", "Taskfile.yml")
+	git("commit", "--quiet", "-m", "init")
+	commit := git("rev-parse", "HEAD")
+
+	var buff bytes.Buffer
+	e := task.NewExecutor(
+		task.WithDir(dir),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(true),
+	)
+	require.NoError(t, e.Setup())
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+	assert.Equal(t, "feature "+commit+"\n", buff.String())
+
+	buff.Reset()
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "must"}))
+	assert.Equal(t, commit+"\n", buff.String())
+}
+
+func TestStrict(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/strict"
+	tests := []struct {
+		name           string
+		taskfile       string
+		strict         bool
+		task           string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{name: "defined variable", taskfile: "Taskfile.yml", task: "default", expectedOutput: "1.0.0\n"},
+		{name: "undefined variable in command", taskfile: "Taskfile.yml", task: "typo", expectedErr: `task: Variable "VERISON" is not defined in template "echo {{.VERISON}}" of task "typo" (`},
+		{name: "undefined variable in variable", taskfile: "Taskfile.yml", task: "typo-var", expectedErr: `task: Variable "VERISON" is not defined in template "v{{.VERISON}}" of task "typo-var" (`},
+		{name: "undefined variable in deferred command", taskfile: "Taskfile.yml", task: "typo-defer", expectedErr: `task: Variable "BUILD_DIRR" is not defined in template "echo \"cleanup [{{.BUILD_DIRR}}]\"" of task "typo-defer" (`},
+		{name: "index of undefined variable", taskfile: "Taskfile.yml", task: "optional", expectedOutput: "\n"},
+		{name: "default of undefined variable", taskfile: "Taskfile.yml", task: "optional-default", expectedOutput: "a b\n"},
+		{name: "condition on undefined variable", taskfile: "Taskfile.yml", task: "optional-if", expectedOutput: "unset empty\n"},
+		{name: "undefined variable in condition body", taskfile: "Taskfile.yml", task: "optional-if-body", expectedErr: `task: Variable "VERISON" is not defined in template`},
+		{name: "not strict", taskfile: "Taskfile.loose.yml", task: "typo", expectedOutput: "\n"},
+		{name: "strict flag", taskfile: "Taskfile.loose.yml", strict: true, task: "typo", expectedErr: `task: Variable "VERISON" is not defined`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithEntrypoint(dir+"/"+test.taskfile),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+				task.WithStrict(test.strict),
+			)
+			require.NoError(t, e.Setup())
+			err := e.Run(t.Context(), &task.Call{Task: test.task})
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				var undefinedVarErr *errors.TaskUndefinedVarError
+				require.ErrorAs(t, err, &undefinedVarErr)
+				assert.Contains(t, undefinedVarErr.Location, test.taskfile+":")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/profiles"
+	tests := []struct {
+		name           string
+		profile        string
+		task           string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{name: "no profile", task: "default", expectedOutput: " local 1 debug\n"},
+		{name: "overrides vars", profile: "staging", task: "default", expectedOutput: "staging eu-west-1 1 debug\n"},
+		{name: "overrides vars and env", profile: "prod", task: "default", expectedOutput: "prod us-east-1 3 warn\n"},
+		{name: "allowed profile", profile: "prod", task: "deploy", expectedOutput: "deploying to us-east-1\n"},
+		{name: "missing allowed profile", task: "deploy", expectedErr: `task: Task "deploy" cancelled because it requires a profile (allowed profiles : [staging prod])`},
+		{name: "any profile", profile: "staging", task: "migrate", expectedOutput: "migrating eu-west-1\n"},
+		{name: "missing any profile", task: "migrate", expectedErr: `task: Task "migrate" cancelled because it requires a profile`},
+		{name: "included task without profile", task: "lib:show", expectedOutput: "lib local  1\n"},
+		{name: "included task", profile: "prod", task: "lib:show", expectedOutput: "lib us-east-1 prod 1\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+				task.WithProfile(test.profile),
+			)
+			require.NoError(t, e.Setup())
+			err := e.Run(t.Context(), &task.Call{Task: test.task})
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedErr, err.Error())
+				var profileErr *errors.TaskProfileRequiredError
+				require.ErrorAs(t, err, &profileErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+
+	t.Run("unknown profile", func(t *testing.T) {
+		t.Parallel()
+
+		e := task.NewExecutor(
+			task.WithDir(dir),
+			task.WithStdout(io.Discard),
+			task.WithStderr(io.Discard),
+			task.WithProfile("qa"),
+		)
+		err := e.Setup()
+		require.Error(t, err)
+		assert.Equal(t, `task: Profile "qa" does not exist (available profiles : [prod staging])`, err.Error())
+	})
+}
+
+func TestExplainVars(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/explain_vars"
+	tests := []struct {
+		name     string
+		task     string
+		expected []string
+	}{
+		{
+			name: "task vars shadow taskfile vars",
+			task: "default",
+			expected: []string{
+				"GREETING: \"hi\"\n  from task vars (testdata/explain_vars/Taskfile.yml:18:7)\n  shadows \"hello\" from taskfile vars (testdata/explain_vars/Taskfile.yml:6:3)\n",
+				"UPPER: \"WORLD\"\n  from task vars (testdata/explain_vars/Taskfile.yml:19:7), sh: echo {{.TARGET}} | tr a-z A-Z\n",
+				"REGION: \"eu\"\n  from dotenv (testdata/explain_vars/.env)\n",
+			},
+		},
+		{
+			name: "include vars",
+			task: "lib:default",
+			expected: []string{
+				"LIB_NAME: \"lib\"\n  from include vars (testdata/explain_vars/Taskfile.yml:13:7)\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithExplainVars(true),
+			)
+			require.NoError(t, e.Setup())
+			require.NoError(t, e.Run(t.Context(), &task.Call{Task: test.task}))
+			output := filepath.ToSlash(buff.String())
+			for _, expected := range test.expected {
+				assert.Contains(t, output, expected)
+			}
+		})
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/secrets"
+
+	newExecutor := func(buff *bytes.Buffer, calls *int, opts ...task.ExecutorOption) *task.Executor {
+		var mu sync.Mutex
+		fake := task.SecretProviderFunc(func(ctx context.Context, req *task.SecretRequest) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			*calls++
+			return req.Source + "-" + req.Key, nil
+		})
+		return task.NewExecutor(append([]task.ExecutorOption{
+			task.WithDir(dir),
+			task.WithStdout(buff),
+			task.WithStderr(buff),
+			task.WithSecretProvider("fake", fake),
+		}, opts...)...)
+	}
+
+	t.Run("command provider", func(t *testing.T) {
+		t.Parallel()
+
+		var buff bytes.Buffer
+		var calls int
+		e := newExecutor(&buff, &calls)
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+		assert.Equal(t, "task: [default] echo \"token=*****\"\ntoken=s3cr3t-token\n", buff.String())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Parallel()
+
+		var buff bytes.Buffer
+		var calls int
+		e := newExecutor(&buff, &calls, task.WithSilent(true))
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "env"}))
+		assert.Equal(t, "api=s3cr3t-env\n", buff.String())
+	})
+
+	t.Run("custom provider is cached", func(t *testing.T) {
+		t.Parallel()
+
+		var buff bytes.Buffer
+		var calls int
+		e := newExecutor(&buff, &calls, task.WithSilent(true))
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "cached"}))
+		assert.Equal(t, "db-password\ndb-password\n", buff.String())
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("cached by directory", func(t *testing.T) {
+		t.Parallel()
+
+		var buff bytes.Buffer
+		var calls int
+		e := newExecutor(&buff, &calls, task.WithSilent(true), task.WithEntrypoint(dir+"/Taskfile.dirs.yml"))
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+		assert.Equal(t, "db-password\ndb-password\n", buff.String())
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("redact while resolving", func(t *testing.T) {
+		t.Parallel()
+
+		var buff bytes.Buffer
+		var e *task.Executor
+		slow := task.SecretProviderFunc(func(ctx context.Context, req *task.SecretRequest) (string, error) {
+			redacted := make(chan struct{})
+			go func() {
+				e.Compiler.Redact("output")
+				close(redacted)
+			}()
+			select {
+			case <-redacted:
+				return "password", nil
+			case <-time.After(5 * time.Second):
+				return "", fmt.Errorf("redacting output was blocked")
+			}
+		})
+		e = task.NewExecutor(
+			task.WithDir(dir),
+			task.WithStdout(&buff),
+			task.WithStderr(&buff),
+			task.WithSilent(true),
+			task.WithSecretProvider("fake", slow),
+		)
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "cached"}))
+		assert.Equal(t, "password\npassword\n", buff.String())
+	})
+
+	t.Run("summary is redacted", func(t *testing.T) {
+		t.Parallel()
+
+		var buff bytes.Buffer
+		var calls int
+		e := newExecutor(&buff, &calls, task.WithSummary(true))
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+		assert.Contains(t, buff.String(), "TOKEN: secret: command (redacted)\n")
+		assert.NotContains(t, buff.String(), "s3cr3t")
+		assert.Equal(t, 0, calls)
+	})
+}
+
+func TestDynamicVarCache(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/var_cache"
+	t.Cleanup(func() {
+		_ = os.RemoveAll(filepath.Join(dir, ".task"))
+		for _, name := range []string{"source.txt", "sources.log", "ttl.log", "expired.log", "env.log", "uncached.log"} {
+			_ = os.Remove(filepath.Join(dir, name))
+		}
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "source.txt"), []byte("v1\n"), 0o644))
+
+	runCall := func(call *task.Call) string {
+		var buff bytes.Buffer
+		e := task.NewExecutor(
+			task.WithDir(dir),
+			task.WithStdout(&buff),
+			task.WithStderr(&buff),
+			task.WithSilent(true),
+		)
+		require.NoError(t, e.Setup())
+		require.NoError(t, e.Run(t.Context(), call))
+		return buff.String()
+	}
+	run := func(taskName string) string {
+		return runCall(&task.Call{Task: taskName})
+	}
+	runs := func(log string) int {
+		b, err := os.ReadFile(filepath.Join(dir, log))
+		require.NoError(t, err)
+		return strings.Count(string(b), "run\n")
+	}
+
+	// Results are reused by the following runs until the sources change
+	assert.Equal(t, "v1\n", run("sources"))
+	assert.Equal(t, "v1\n", run("sources"))
+	assert.Equal(t, 1, runs("sources.log"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "source.txt"), []byte("v2\n"), 0o644))
+	assert.Equal(t, "v2\n", run("sources"))
+	assert.Equal(t, 2, runs("sources.log"))
+
+	// Results are reused until they expire
+	assert.Equal(t, "cached\n", run("ttl"))
+	assert.Equal(t, "cached\n", run("ttl"))
+	assert.Equal(t, 1, runs("ttl.log"))
+	assert.Equal(t, "stale\n", run("expired"))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, "stale\n", run("expired"))
+	assert.Equal(t, 2, runs("expired.log"))
+
+	// The environment variables that the command references are part of the
+	// key of the cache
+	greeting := func(value string) *task.Call {
+		vars := ast.NewVars()
+		vars.Set("GREETING", ast.Var{Value: value})
+		return &task.Call{Task: "env", Vars: vars}
+	}
+	assert.Equal(t, "hello\n", runCall(greeting("hello")))
+	assert.Equal(t, "bonjour\n", runCall(greeting("bonjour")))
+	assert.Equal(t, "hello\n", runCall(greeting("hello")))
+	assert.Equal(t, 2, runs("env.log"))
+
+	// The results are only readable by the user
+	if runtime.GOOS != "windows" {
+		files, err := filepath.Glob(filepath.Join(dir, ".task", "vars", "*.json"))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), file)
+		}
+	}
+
+	// Variables without a cache run every time
+	assert.Equal(t, "fresh\n", run("uncached"))
+	assert.Equal(t, "fresh\n", run("uncached"))
+	assert.Equal(t, 2, runs("uncached.log"))
+}
+
+func TestDynamicVarCacheSecret(t *testing.T) {
+	t.Parallel()
+
+	const dir = "testdata/var_cache_secret"
+	tests := []struct {
+		name        string
+		taskfile    string
+		expectedErr string
+	}{
+		{name: "template", taskfile: "Taskfile.yml", expectedErr: `task: Variable "USER" can't be cached since its command uses the secret variable "TOKEN"`},
+		{name: "environment", taskfile: "Taskfile.env.yml", expectedErr: `task: Variable "USER" can't be cached since its command uses the secret variable "API_TOKEN"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := task.NewExecutor(
+				task.WithDir(dir),
+				task.WithEntrypoint(dir+"/"+test.taskfile),
+				task.WithStdout(io.Discard),
+				task.WithStderr(io.Discard),
+			)
+			err := e.Setup()
+			require.Error(t, err)
+			assert.Equal(t, test.expectedErr, err.Error())
+		})
+	}
+}
+
 func TestSingleCmdDep(t *testing.T) {
 	t.Parallel()
 
@@ -2652,6 +3668,116 @@ func TestWildcard(t *testing.T) {
 	}
 }
 
+func TestInterpreter(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+
+	tests := []struct {
+		call           string
+		expectedOutput string
+		wantErr        bool
+	}{
+		{call: "task-level", expectedOutput: "hello world\n"},
+		{call: "cmd-level", expectedOutput: "subdir\n"},
+		{call: "ignore-error", expectedOutput: "after\n"},
+		{call: "fail", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.call, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			e := task.NewExecutor(
+				task.WithDir("testdata/interpreter"),
+				task.WithStdout(&buff),
+				task.WithStderr(&buff),
+				task.WithSilent(true),
+			)
+			require.NoError(t, e.Setup())
+			err := e.Run(t.Context(), &task.Call{Task: test.call})
+			if test.wantErr {
+				var runErr *errors.TaskRunError
+				require.ErrorAs(t, err, &runErr)
+				assert.Equal(t, 3, runErr.TaskExitCode())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, buff.String())
+		})
+	}
+}
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	var buff bytes.Buffer
+	e := task.NewExecutor(
+		task.WithDir("testdata/resources"),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(true),
+	)
+	require.NoError(t, e.Setup())
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+
+	// Tasks using the db pool must not overlap
+	var lines []string
+	for line := range strings.Lines(buff.String()) {
+		if line != "lint\n" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	require.Len(t, lines, 4)
+	for i := 0; i < len(lines); i += 2 {
+		name := strings.TrimPrefix(lines[i], "start ")
+		assert.Equal(t, "end "+name, lines[i+1])
+	}
+
+	// A task holding a pool can call tasks using the same pool
+	buff.Reset()
+	require.NoError(t, e.Run(t.Context(), &task.Call{Task: "nested"}))
+	assert.Equal(t, "start migrate\nend migrate\n", buff.String())
+}
+
+func TestResourcesConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var buff SyncBuffer
+	e := task.NewExecutor(
+		task.WithDir("testdata/resources"),
+		task.WithStdout(&buff),
+		task.WithStderr(&buff),
+		task.WithSilent(true),
+		task.WithConcurrency(2),
+	)
+	require.NoError(t, e.Setup())
+
+	// The task waiting for the db pool does not hold a slot meanwhile, so
+	// lint runs before the pool is released, whichever order the tasks start
+	for range 5 {
+		buff.buf.Reset()
+		require.NoError(t, e.Run(t.Context(), &task.Call{Task: "default"}))
+		out := buff.buf.String()
+		assert.Less(t, strings.Index(out, "lint\n"), strings.Index(out, "end "), out)
+	}
+}
+
+func TestResourcesUndeclared(t *testing.T) {
+	t.Parallel()
+
+	e := task.NewExecutor(
+		task.WithDir("testdata/resources/undeclared"),
+		task.WithStdout(io.Discard),
+		task.WithStderr(io.Discard),
+	)
+	err := e.Setup()
+	require.ErrorContains(t, err, `task "default" uses undeclared resource "gpu"`)
+}
+
 // enableExperimentForTest enables the experiment behind pointer e for the duration of test t and sub-tests,
 // with the experiment being restored to its previous state when tests complete.
 //
@@ -0,0 +1,63 @@
+package ast
+
+import (
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/errors"
+	"github.com/vikbert/taskr/v3/internal/deepcopy"
+)
+
+// Container represents the container image in which the commands of a task
+// are run
+type Container struct {
+	Image   string
+	Volumes []string
+	Env     *Vars
+	Workdir string
+}
+
+func (c *Container) DeepCopy() *Container {
+	if c == nil {
+		return nil
+	}
+	return &Container{
+		Image:   c.Image,
+		Volumes: deepcopy.Slice(c.Volumes),
+		Env:     c.Env.DeepCopy(),
+		Workdir: c.Workdir,
+	}
+}
+
+func (c *Container) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+
+	case yaml.ScalarNode:
+		var image string
+		if err := node.Decode(&image); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		c.Image = image
+		return nil
+
+	case yaml.MappingNode:
+		var container struct {
+			Image   string
+			Volumes []string
+			Env     *Vars
+			Workdir string
+		}
+		if err := node.Decode(&container); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		if container.Image == "" {
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage("container must have an image")
+		}
+		c.Image = container.Image
+		c.Volumes = container.Volumes
+		c.Env = container.Env
+		c.Workdir = container.Workdir
+		return nil
+	}
+
+	return errors.NewTaskfileDecodeError(nil, node).WithTypeMessage("container")
+}
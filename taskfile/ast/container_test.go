@@ -0,0 +1,55 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+func TestContainerParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		content  string
+		v        any
+		expected any
+	}{
+		{
+			"golang:1.24",
+			&ast.Container{},
+			&ast.Container{Image: "golang:1.24"},
+		},
+		{
+			`
+image: golang:1.24
+volumes: ["~/.cache/go-build:/root/.cache/go-build"]
+workdir: /src
+`,
+			&ast.Container{},
+			&ast.Container{
+				Image:   "golang:1.24",
+				Volumes: []string{"~/.cache/go-build:/root/.cache/go-build"},
+				Workdir: "/src",
+			},
+		},
+	}
+	for _, test := range tests {
+		err := yaml.Unmarshal([]byte(test.content), test.v)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, test.v)
+	}
+
+	var c ast.Container
+	err := yaml.Unmarshal([]byte("image: alpine\nenv:\n  FOO: bar\n"), &c)
+	require.NoError(t, err)
+	foo, ok := c.Env.Get("FOO")
+	require.True(t, ok)
+	assert.Equal(t, "bar", foo.Value)
+
+	err = yaml.Unmarshal([]byte("volumes: [./data:/data]"), &c)
+	require.Error(t, err)
+}
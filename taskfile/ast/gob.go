@@ -0,0 +1,306 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/elliotchance/orderedmap/v3"
+)
+
+// Taskfiles are encoded with encoding/gob so that they can be cached between
+// runs. Ordered maps are encoded as lists of key-value pairs to keep their
+// order.
+
+func init() {
+	// Register the types that YAML decodes into interface values
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+}
+
+type gobPair[V any] struct {
+	Key   string
+	Value V
+}
+
+func gobEncodeOrderedMap[V any](om *orderedmap.OrderedMap[string, V]) ([]byte, error) {
+	pairs := []gobPair[V]{}
+	if om != nil {
+		for key, value := range om.AllFromFront() {
+			pairs = append(pairs, gobPair[V]{Key: key, Value: value})
+		}
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(pairs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobDecodeOrderedMap[V any](b []byte) (*orderedmap.OrderedMap[string, V], error) {
+	var pairs []gobPair[V]
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&pairs); err != nil {
+		return nil, err
+	}
+	om := orderedmap.NewOrderedMap[string, V]()
+	for _, pair := range pairs {
+		om.Set(pair.Key, pair.Value)
+	}
+	return om, nil
+}
+
+// GobEncode implements gob.GobEncoder
+func (tasks *Tasks) GobEncode() ([]byte, error) {
+	defer tasks.mutex.RUnlock()
+	tasks.mutex.RLock()
+	return gobEncodeOrderedMap(tasks.om)
+}
+
+// GobDecode implements gob.GobDecoder
+func (tasks *Tasks) GobDecode(b []byte) error {
+	om, err := gobDecodeOrderedMap[*Task](b)
+	if err != nil {
+		return err
+	}
+	tasks.om = om
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder
+func (vars *Vars) GobEncode() ([]byte, error) {
+	defer vars.mutex.RUnlock()
+	vars.mutex.RLock()
+	return gobEncodeOrderedMap(vars.om)
+}
+
+// GobDecode implements gob.GobDecoder
+func (vars *Vars) GobDecode(b []byte) error {
+	om, err := gobDecodeOrderedMap[Var](b)
+	if err != nil {
+		return err
+	}
+	vars.om = om
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder
+func (includes *Includes) GobEncode() ([]byte, error) {
+	defer includes.mutex.RUnlock()
+	includes.mutex.RLock()
+	return gobEncodeOrderedMap(includes.om)
+}
+
+// GobDecode implements gob.GobDecoder
+func (includes *Includes) GobDecode(b []byte) error {
+	om, err := gobDecodeOrderedMap[*Include](b)
+	if err != nil {
+		return err
+	}
+	includes.om = om
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder
+func (matrix *Matrix) GobEncode() ([]byte, error) {
+	return gobEncodeOrderedMap(matrix.om)
+}
+
+// GobDecode implements gob.GobDecoder
+func (matrix *Matrix) GobDecode(b []byte) error {
+	om, err := gobDecodeOrderedMap[*MatrixRow](b)
+	if err != nil {
+		return err
+	}
+	matrix.om = om
+	return nil
+}
+
+// gobTaskfile is the encoded form of a [Taskfile]. Gob can't encode semantic
+// versions, so the version is sent as a string.
+type gobTaskfile struct {
+	Location   string
+	Version    string
+	Project    string
+	Output     Output
+	Method     string
+	Includes   *Includes
+	Set        []string
+	Shopt      []string
+	Vars       *Vars
+	Env        *Vars
+	Tasks      *Tasks
+	Silent     bool
+	Dotenv     []string
+	Run        string
+	Interval   time.Duration
+	Banner     bool
+	Categories []string
+	Resources  map[string]int
+	Requires   *Requires
+	Exports    []string
+	Strict     bool
+	Profiles   map[string]*Profile
+}
+
+// GobEncode implements gob.GobEncoder
+func (tf *Taskfile) GobEncode() ([]byte, error) {
+	var version string
+	if tf.Version != nil {
+		version = tf.Version.Original()
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobTaskfile{
+		Location:   tf.Location,
+		Version:    version,
+		Project:    tf.Project,
+		Output:     tf.Output,
+		Method:     tf.Method,
+		Includes:   tf.Includes,
+		Set:        tf.Set,
+		Shopt:      tf.Shopt,
+		Vars:       tf.Vars,
+		Env:        tf.Env,
+		Tasks:      tf.Tasks,
+		Silent:     tf.Silent,
+		Dotenv:     tf.Dotenv,
+		Run:        tf.Run,
+		Interval:   tf.Interval,
+		Banner:     tf.Banner,
+		Categories: tf.Categories,
+		Resources:  tf.Resources,
+		Requires:   tf.Requires,
+		Exports:    tf.Exports,
+		Strict:     tf.Strict,
+		Profiles:   tf.Profiles,
+	})
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder
+func (tf *Taskfile) GobDecode(b []byte) error {
+	var gtf gobTaskfile
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&gtf); err != nil {
+		return err
+	}
+	*tf = Taskfile{
+		Location:   gtf.Location,
+		Project:    gtf.Project,
+		Output:     gtf.Output,
+		Method:     gtf.Method,
+		Includes:   gtf.Includes,
+		Set:        gtf.Set,
+		Shopt:      gtf.Shopt,
+		Vars:       gtf.Vars,
+		Env:        gtf.Env,
+		Tasks:      gtf.Tasks,
+		Silent:     gtf.Silent,
+		Dotenv:     gtf.Dotenv,
+		Run:        gtf.Run,
+		Interval:   gtf.Interval,
+		Banner:     gtf.Banner,
+		Categories: gtf.Categories,
+		Resources:  gtf.Resources,
+		Requires:   gtf.Requires,
+		Exports:    gtf.Exports,
+		Strict:     gtf.Strict,
+		Profiles:   gtf.Profiles,
+	}
+	if gtf.Version != "" {
+		version, err := semver.NewVersion(gtf.Version)
+		if err != nil {
+			return err
+		}
+		tf.Version = version
+	}
+	return nil
+}
+
+// gobVar is the encoded form of a [Var]. Gob does not send pointers to zero
+// values, so whether the variable is dynamic is sent separately.
+type gobVar struct {
+	Value    any
+	Live     any
+	Sh       string
+	IsSh     bool
+	Cache    *VarCache
+	Ref      string
+	Secret   *Secret
+	Dir      string
+	Location *Location
+}
+
+// GobEncode implements gob.GobEncoder
+func (v Var) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobVar{
+		Value:    v.Value,
+		Live:     v.Live,
+		Sh:       deref(v.Sh),
+		IsSh:     v.Sh != nil,
+		Cache:    v.Cache,
+		Ref:      v.Ref,
+		Secret:   v.Secret,
+		Dir:      v.Dir,
+		Location: v.Location,
+	})
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder
+func (v *Var) GobDecode(b []byte) error {
+	var gv gobVar
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&gv); err != nil {
+		return err
+	}
+	*v = Var{
+		Value:    gv.Value,
+		Live:     gv.Live,
+		Cache:    gv.Cache,
+		Ref:      gv.Ref,
+		Secret:   gv.Secret,
+		Dir:      gv.Dir,
+		Location: gv.Location,
+	}
+	if gv.IsSh {
+		v.Sh = &gv.Sh
+	}
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// gobOptionalInt is the encoded form of an [OptionalInt]. Gob does not send
+// pointers to zero values, so whether the value is set is sent separately.
+type gobOptionalInt struct {
+	Value int
+	IsSet bool
+}
+
+// GobEncode implements gob.GobEncoder
+func (o OptionalInt) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobOptionalInt{
+		Value: o.Get(),
+		IsSet: o.IsSet(),
+	})
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder
+func (o *OptionalInt) GobDecode(b []byte) error {
+	var goi gobOptionalInt
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&goi); err != nil {
+		return err
+	}
+	o.Value = nil
+	if goi.IsSet {
+		o.Value = &goi.Value
+	}
+	return nil
+}
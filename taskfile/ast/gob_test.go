@@ -0,0 +1,171 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestTaskfileGob(t *testing.T) {
+	t.Parallel()
+
+	const content = `
+version: '3'
+output: prefixed
+vars:
+  STATIC: value
+  LIST: [a, b]
+  MAP:
+    map: {a: 1, b: [true]}
+  DYNAMIC:
+    sh: ''
+  REF:
+    ref: .STATIC
+includes:
+  lib:
+    taskfile: ./lib
+    vars:
+      FOO: bar
+tasks:
+  default:
+    desc: A task
+    deps: [build]
+    cmds:
+      - echo {{.STATIC}}
+      - for: [a, b]
+        cmd: echo {{.ITEM}}
+      - for:
+          matrix:
+            OS: [linux, darwin]
+        cmd: echo {{.ITEM.OS}}
+    sources:
+      - '**/*.go'
+      - exclude: vendor/**
+    requires:
+      vars: [STATIC]
+  build: go build
+  empty:
+`
+	var tf Taskfile
+	require.NoError(t, yaml.Unmarshal([]byte(content), &tf))
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&tf))
+	var decoded Taskfile
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+
+	assert.True(t, tf.Version.Equal(decoded.Version))
+	assert.Equal(t, tf.Output, decoded.Output)
+	assert.Equal(t, toMap(tf.Vars.All()), toMap(decoded.Vars.All()))
+	assert.Equal(t, toMap(tf.Includes.All()), toMap(decoded.Includes.All()))
+	assert.Equal(t, toMap(tf.Tasks.All(nil)), toMap(decoded.Tasks.All(nil)))
+	assert.Equal(t, slices.Collect(tf.Tasks.Keys(nil)), slices.Collect(decoded.Tasks.Keys(nil)))
+
+	sh, ok := decoded.Vars.Get("DYNAMIC")
+	require.True(t, ok)
+	require.NotNil(t, sh.Sh)
+	assert.Empty(t, *sh.Sh)
+}
+
+// TestTaskGob makes sure that every field of a [Task] survives the encoding,
+// including the zero values that gob drops.
+func TestTaskGob(t *testing.T) {
+	t.Parallel()
+
+	const content = `
+cmds:
+  - echo {{.NAME}}
+deps: [build]
+label: label
+desc: desc
+prompt: Are you sure?
+summary: summary
+category: category
+requires:
+  vars: [NAME]
+  profile: [prod]
+aliases: [a]
+sources: ['**/*.go']
+generates: [bin/app]
+status: ['test -f bin/app']
+preconditions: ['true']
+dir: dir
+set: [pipefail]
+shopt: [globstar]
+vars:
+  NAME: value
+  EMPTY:
+    sh: ''
+env:
+  KEY: value
+dotenv: [.env]
+silent: true
+interactive: true
+internal: true
+method: timestamp
+prefix: prefix
+ignore_error: true
+run: once
+platforms: [linux]
+container: alpine
+interpreter: bash
+hosts: [a]
+uses: [db]
+log: logs
+watch: true
+failfast: true
+index: 0
+`
+	var task Task
+	require.NoError(t, yaml.Unmarshal([]byte(content), &task))
+	// Fields that are not read from YAML
+	task.Task = "task"
+	task.Location = &Location{Taskfile: "Taskfile.yml", Line: 1, Column: 1}
+	task.Strict = true
+	task.Namespace = "ns"
+	task.IncludeVars = NewVars(&VarElement{Key: "INCLUDE", Value: Var{Value: "value"}})
+	task.IncludedTaskfileVars = NewVars(&VarElement{Key: "INCLUDED", Value: Var{Value: "value"}})
+	task.IncludedTaskfileDotenv = []string{".env"}
+	task.FullName = "ns:task"
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&task))
+	var decoded Task
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+
+	original := reflect.ValueOf(task)
+	roundTripped := reflect.ValueOf(decoded)
+	for i := range original.NumField() {
+		name := original.Type().Field(i).Name
+		require.False(t, original.Field(i).IsZero(), "field %s is not covered by the test", name)
+		assert.Equal(t, original.Field(i).Interface(), roundTripped.Field(i).Interface(), "field %s", name)
+	}
+	assert.True(t, decoded.Index.IsSet())
+	assert.Equal(t, 0, decoded.Index.Get())
+}
+
+// TestTaskfileGobFields makes sure that every field of a [Taskfile] is encoded.
+func TestTaskfileGobFields(t *testing.T) {
+	t.Parallel()
+
+	taskfileType := reflect.TypeFor[Taskfile]()
+	gobType := reflect.TypeFor[gobTaskfile]()
+	require.Equal(t, taskfileType.NumField(), gobType.NumField())
+	for i := range taskfileType.NumField() {
+		assert.Equal(t, taskfileType.Field(i).Name, gobType.Field(i).Name)
+	}
+}
+
+func toMap[V any](seq func(func(string, V) bool)) map[string]V {
+	m := map[string]V{}
+	for k, v := range seq {
+		m[k] = v
+	}
+	return m
+}
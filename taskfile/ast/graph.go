@@ -1,8 +1,13 @@
 package ast
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math"
 	"os"
+	"path"
+	"slices"
 	"sync"
 
 	"github.com/dominikbraun/graph"
@@ -19,6 +24,9 @@ type TaskfileGraph struct {
 type TaskfileVertex struct {
 	URI      string
 	Taskfile *Taskfile
+	// Checksum is the checksum of the content of the Taskfile. It is only set
+	// for local Taskfiles.
+	Checksum string
 }
 
 func taskfileHash(vertex *TaskfileVertex) string {
@@ -45,8 +53,12 @@ func (tfg *TaskfileGraph) Visualize(filename string) error {
 	return draw.DOT(tfg.Graph, f)
 }
 
+// Merge merges every included Taskfile into the Taskfiles that include it and
+// returns the root Taskfile. Taskfiles are merged in the order they are
+// declared, regardless of the order they were read in, so that the result is
+// always the same.
 func (tfg *TaskfileGraph) Merge() (*Taskfile, error) {
-	hashes, err := graph.TopologicalSort(tfg.Graph)
+	hashes, err := tfg.mergeOrder()
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +68,10 @@ func (tfg *TaskfileGraph) Merge() (*Taskfile, error) {
 		return nil, err
 	}
 
-	// Loop over each vertex in reverse topological order except for the root vertex.
-	// This gives us a loop over every included Taskfile in an order which is safe to merge.
-	for i := len(hashes) - 1; i > 0; i-- {
-		hash := hashes[i]
-
+	// Loop over each vertex in merge order except for the root vertex, which
+	// comes last. This gives us a loop over every included Taskfile in an order
+	// which is safe to merge.
+	for _, hash := range hashes[:len(hashes)-1] {
 		// Get the included vertex
 		includedVertex, err := tfg.Vertex(hash)
 		if err != nil {
@@ -88,7 +99,7 @@ func (tfg *TaskfileGraph) Merge() (*Taskfile, error) {
 				}
 
 				// Merge the included Taskfiles into the parent Taskfile
-				for _, include := range includes {
+				for _, include := range sortIncludes(vertex.Taskfile, includes) {
 					if err := vertex.Taskfile.Merge(
 						includedVertex.Taskfile,
 						include,
@@ -111,10 +122,98 @@ func (tfg *TaskfileGraph) Merge() (*Taskfile, error) {
 	}
 
 	// Get the root vertex
-	rootVertex, err := tfg.Vertex(hashes[0])
+	rootVertex, err := tfg.Vertex(hashes[len(hashes)-1])
 	if err != nil {
 		return nil, err
 	}
 
 	return rootVertex.Taskfile, nil
 }
+
+// mergeOrder returns the hashes of every vertex in the order they should be
+// merged. Every Taskfile comes after the Taskfiles it includes and the root
+// Taskfile comes last. Includes are visited in the order they are declared.
+func (tfg *TaskfileGraph) mergeOrder() ([]string, error) {
+	hashes, err := graph.TopologicalSort(tfg.Graph)
+	if err != nil {
+		return nil, err
+	}
+	adjacencyMap, err := tfg.AdjacencyMap()
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]string, 0, len(hashes))
+	visited := make(map[string]bool, len(hashes))
+	var visit func(hash string) error
+	visit = func(hash string) error {
+		if visited[hash] {
+			return nil
+		}
+		visited[hash] = true
+		vertex, err := tfg.Vertex(hash)
+		if err != nil {
+			return err
+		}
+		for _, target := range includedHashes(vertex.Taskfile, adjacencyMap[hash]) {
+			if err := visit(target); err != nil {
+				return err
+			}
+		}
+		order = append(order, hash)
+		return nil
+	}
+	// The first vertex of a topological sort is the root vertex
+	if err := visit(hashes[0]); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// includedHashes returns the hashes of the Taskfiles included by the given
+// Taskfile in the order their includes are declared.
+func includedHashes(tf *Taskfile, edges map[string]graph.Edge[string]) []string {
+	targets := slices.Sorted(maps.Keys(edges))
+	index := func(target string) int {
+		includes, _ := edges[target].Properties.Data.([]*Include)
+		i := math.MaxInt
+		for _, include := range includes {
+			i = min(i, includeIndex(tf, include))
+		}
+		return i
+	}
+	slices.SortStableFunc(targets, func(a, b string) int {
+		return cmp.Compare(index(a), index(b))
+	})
+	return targets
+}
+
+// sortIncludes returns the given includes in the order they are declared in
+// the given Taskfile.
+func sortIncludes(tf *Taskfile, includes []*Include) []*Include {
+	return slices.SortedStableFunc(slices.Values(includes), func(a, b *Include) int {
+		return cmp.Compare(includeIndex(tf, a), includeIndex(tf, b))
+	})
+}
+
+// includeIndex returns the position of the given include in the given
+// Taskfile. Includes expanded from a glob include take its position.
+func includeIndex(tf *Taskfile, include *Include) int {
+	var i int
+	for namespace := range tf.Includes.Keys() {
+		if namespace == include.Namespace {
+			return i
+		}
+		i++
+	}
+	i = 0
+	for namespace := range tf.Includes.Keys() {
+		if IsGlobNamespace(namespace) {
+			if ok, _ := path.Match(namespace, include.Namespace); ok {
+				return i
+			}
+		}
+		i++
+	}
+	return i
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"iter"
+	"strings"
 	"sync"
 
 	"github.com/elliotchance/orderedmap/v3"
@@ -25,6 +26,8 @@ type (
 		Vars           *Vars
 		Flatten        bool
 		Checksum       string
+		Platforms      []*Platform
+		If             string
 	}
 	// Includes is an ordered map of namespaces to includes.
 	Includes struct {
@@ -36,6 +39,13 @@ type (
 	IncludeElement orderedmap.Element[string, *Include]
 )
 
+// IsGlobNamespace reports whether the given include namespace contains a
+// wildcard. Such includes are expanded into an include for every Taskfile
+// matched by their path.
+func IsGlobNamespace(namespace string) bool {
+	return strings.Contains(namespace, "*")
+}
+
 // NewIncludes creates a new instance of Includes and initializes it with the
 // provided set of elements, if any. The elements are added in the order they
 // are passed.
@@ -158,15 +168,17 @@ func (include *Include) UnmarshalYAML(node *yaml.Node) error {
 
 	case yaml.MappingNode:
 		var includedTaskfile struct {
-			Taskfile string
-			Dir      string
-			Optional bool
-			Internal bool
-			Flatten  bool
-			Aliases  []string
-			Excludes []string
-			Vars     *Vars
-			Checksum string
+			Taskfile  string
+			Dir       string
+			Optional  bool
+			Internal  bool
+			Flatten   bool
+			Aliases   []string
+			Excludes  []string
+			Vars      *Vars
+			Checksum  string
+			Platforms []*Platform
+			If        string
 		}
 		if err := node.Decode(&includedTaskfile); err != nil {
 			return errors.NewTaskfileDecodeError(err, node)
@@ -181,6 +193,8 @@ func (include *Include) UnmarshalYAML(node *yaml.Node) error {
 		include.Vars = includedTaskfile.Vars
 		include.Flatten = includedTaskfile.Flatten
 		include.Checksum = includedTaskfile.Checksum
+		include.Platforms = includedTaskfile.Platforms
+		include.If = includedTaskfile.If
 		return nil
 	}
 
@@ -205,5 +219,7 @@ func (include *Include) DeepCopy() *Include {
 		Flatten:        include.Flatten,
 		Aliases:        deepcopy.Slice(include.Aliases),
 		Checksum:       include.Checksum,
+		Platforms:      deepcopy.Slice(include.Platforms),
+		If:             include.If,
 	}
 }
@@ -1,17 +1,30 @@
 package ast
 
 import (
+	"fmt"
+
 	"go.yaml.in/yaml/v4"
 
 	"github.com/vikbert/taskr/v3/errors"
 )
 
+// The ways the lines of the output can be timestamped
+const (
+	OutputTimestampsAbsolute = "absolute"
+	OutputTimestampsElapsed  = "elapsed"
+)
+
 // Output of the Task output
 type Output struct {
 	// Name of the Output.
 	Name string `yaml:"-"`
 	// Group specific style
 	Group OutputGroup
+	// Timestamps prefixes each line with the absolute time or the time elapsed
+	// since Task started
+	Timestamps string
+	// Durations prints how long each task took once it finishes
+	Durations bool
 }
 
 // IsSet returns true if and only if a custom output style is set.
@@ -32,17 +45,34 @@ func (s *Output) UnmarshalYAML(node *yaml.Node) error {
 
 	case yaml.MappingNode:
 		var tmp struct {
-			Group *OutputGroup
+			Name       string
+			Group      *OutputGroup
+			Timestamps any
+			Durations  bool
 		}
 		if err := node.Decode(&tmp); err != nil {
 			return errors.NewTaskfileDecodeError(err, node)
 		}
-		if tmp.Group == nil {
-			return errors.NewTaskfileDecodeError(nil, node).WithMessage(`output style must have the "group" key when in mapping form`)
+		if tmp.Group != nil {
+			if tmp.Name != "" && tmp.Name != "group" {
+				return errors.NewTaskfileDecodeError(nil, node).WithMessage(`output style %q does not support the "group" key`, tmp.Name)
+			}
+			tmp.Name = "group"
+		}
+		if tmp.Name == "" {
+			tmp.Name = "interleaved"
+		}
+		timestamps, err := decodeOutputTimestamps(tmp.Timestamps)
+		if err != nil {
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage("%s", err.Error())
 		}
 		*s = Output{
-			Name:  "group",
-			Group: *tmp.Group,
+			Name:       tmp.Name,
+			Timestamps: timestamps,
+			Durations:  tmp.Durations,
+		}
+		if tmp.Group != nil {
+			s.Group = *tmp.Group
 		}
 		return nil
 	}
@@ -63,3 +93,22 @@ func (g *OutputGroup) IsSet() bool {
 	}
 	return g.Begin != "" || g.End != ""
 }
+
+// decodeOutputTimestamps accepts true as a shorthand for absolute timestamps
+func decodeOutputTimestamps(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case bool:
+		if v {
+			return OutputTimestampsAbsolute, nil
+		}
+		return "", nil
+	case string:
+		switch v {
+		case OutputTimestampsAbsolute, OutputTimestampsElapsed:
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf(`output timestamps must be true, %q or %q`, OutputTimestampsAbsolute, OutputTimestampsElapsed)
+}
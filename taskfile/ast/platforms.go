@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"runtime"
 	"strings"
 
 	"go.yaml.in/yaml/v4"
@@ -26,6 +27,21 @@ func (p *Platform) DeepCopy() *Platform {
 	}
 }
 
+// MatchesCurrentPlatform reports whether any of the given platforms matches
+// the OS and architecture that Task is running on. An empty list matches every
+// platform.
+func MatchesCurrentPlatform(platforms []*Platform) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		if (p.OS == "" || p.OS == runtime.GOOS) && (p.Arch == "" || p.Arch == runtime.GOARCH) {
+			return true
+		}
+	}
+	return false
+}
+
 type ErrInvalidPlatform struct {
 	Platform string
 }
@@ -0,0 +1,80 @@
+package ast
+
+import (
+	"maps"
+	"slices"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// Profile represents a named set of variables and environment variables that
+// override those of the Taskfile when it is selected
+type Profile struct {
+	Vars *Vars
+	Env  *Vars
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (p *Profile) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		var profile struct {
+			Vars *Vars
+			Env  *Vars
+		}
+		if err := node.Decode(&profile); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		p.Vars = profile.Vars
+		p.Env = profile.Env
+		return nil
+	}
+
+	return errors.NewTaskfileDecodeError(nil, node).WithTypeMessage("profile")
+}
+
+// Merge merges the variables of the other profile into the profile
+func (p *Profile) Merge(other *Profile, include *Include) {
+	if other == nil {
+		return
+	}
+	if p.Vars == nil {
+		p.Vars = NewVars()
+	}
+	if p.Env == nil {
+		p.Env = NewVars()
+	}
+	p.Vars.Merge(other.Vars, include)
+	p.Env.Merge(other.Env, include)
+}
+
+// ProfileNames returns the sorted names of the profiles of the Taskfile
+func (tf *Taskfile) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(tf.Profiles))
+}
+
+// ApplyProfile overrides the variables and environment variables of the
+// Taskfile with those of the given profile.
+func (tf *Taskfile) ApplyProfile(name string) error {
+	profile, ok := tf.Profiles[name]
+	if !ok {
+		return &errors.TaskfileProfileNotFoundError{
+			Profile:  name,
+			Profiles: tf.ProfileNames(),
+		}
+	}
+	if profile == nil {
+		return nil
+	}
+	if tf.Vars == nil {
+		tf.Vars = NewVars()
+	}
+	if tf.Env == nil {
+		tf.Env = NewVars()
+	}
+	tf.Vars.Override(profile.Vars)
+	tf.Env.Override(profile.Env)
+	return nil
+}
@@ -10,6 +10,9 @@ import (
 // Requires represents a set of required variables necessary for a task to run
 type Requires struct {
 	Vars []*VarsWithValidation
+	// Profile makes the task fail unless it is called with an explicit
+	// profile. It is only supported by the requires of a task.
+	Profile *ProfileRequirement
 }
 
 func (r *Requires) DeepCopy() *Requires {
@@ -18,13 +21,60 @@ func (r *Requires) DeepCopy() *Requires {
 	}
 
 	return &Requires{
-		Vars: deepcopy.Slice(r.Vars),
+		Vars:    deepcopy.Slice(r.Vars),
+		Profile: r.Profile.DeepCopy(),
 	}
 }
 
+// ProfileRequirement is either a boolean that requires any profile or a list
+// of the profiles that are allowed
+type ProfileRequirement struct {
+	Required bool
+	Enum     []string
+}
+
+func (p *ProfileRequirement) DeepCopy() *ProfileRequirement {
+	if p == nil {
+		return nil
+	}
+	return &ProfileRequirement{
+		Required: p.Required,
+		Enum:     deepcopy.Slice(p.Enum),
+	}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (p *ProfileRequirement) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+
+	case yaml.ScalarNode:
+		var required bool
+		if err := node.Decode(&required); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		p.Required = required
+		p.Enum = nil
+		return nil
+
+	case yaml.SequenceNode:
+		var enum []string
+		if err := node.Decode(&enum); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		p.Required = true
+		p.Enum = enum
+		return nil
+	}
+
+	return errors.NewTaskfileDecodeError(nil, node).WithTypeMessage("profile requirement")
+}
+
 type VarsWithValidation struct {
 	Name string
 	Enum []string
+	// Default is the value of the variable when it is not passed to an
+	// included Taskfile. It is only supported by the requires of a Taskfile.
+	Default any
 }
 
 func (v *VarsWithValidation) DeepCopy() *VarsWithValidation {
@@ -32,8 +82,9 @@ func (v *VarsWithValidation) DeepCopy() *VarsWithValidation {
 		return nil
 	}
 	return &VarsWithValidation{
-		Name: v.Name,
-		Enum: v.Enum,
+		Name:    v.Name,
+		Enum:    v.Enum,
+		Default: v.Default,
 	}
 }
 
@@ -52,14 +103,16 @@ func (v *VarsWithValidation) UnmarshalYAML(node *yaml.Node) error {
 
 	case yaml.MappingNode:
 		var vv struct {
-			Name string
-			Enum []string
+			Name    string
+			Enum    []string
+			Default any
 		}
 		if err := node.Decode(&vv); err != nil {
 			return errors.NewTaskfileDecodeError(err, node)
 		}
 		v.Name = vv.Name
 		v.Enum = vv.Enum
+		v.Default = vv.Default
 		return nil
 	}
 
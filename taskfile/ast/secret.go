@@ -0,0 +1,83 @@
+package ast
+
+import (
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// The built-in secret providers
+const (
+	SecretProviderCommand = "command"
+	SecretProviderKeyring = "keyring"
+	SecretProviderFile    = "file"
+)
+
+// Secret represents a variable whose value is read from a secret provider
+type Secret struct {
+	// Provider is the name of the provider that resolves the secret
+	Provider string
+	// Source is what the provider reads the secret from: the command to run,
+	// the service of the keyring or the path of the encrypted file
+	Source string
+	// Key selects the secret in the source: the account of the keyring or the
+	// dotted path of the value in the decrypted file
+	Key string
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var command string
+		if err := node.Decode(&command); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		s.Provider = SecretProviderCommand
+		s.Source = command
+		return nil
+
+	case yaml.MappingNode:
+		var secret struct {
+			Command  string
+			Keyring  string
+			File     string
+			Provider string
+			Source   string
+			Key      string
+		}
+		if err := node.Decode(&secret); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		sources := 0
+		for _, source := range []string{secret.Command, secret.Keyring, secret.File, secret.Provider} {
+			if source != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage(`secret must have exactly one of "command", "keyring", "file" or "provider"`)
+		}
+		switch {
+		case secret.Command != "":
+			s.Provider = SecretProviderCommand
+			s.Source = secret.Command
+		case secret.Keyring != "":
+			s.Provider = SecretProviderKeyring
+			s.Source = secret.Keyring
+		case secret.File != "":
+			s.Provider = SecretProviderFile
+			s.Source = secret.File
+		default:
+			s.Provider = secret.Provider
+			s.Source = secret.Source
+		}
+		s.Key = secret.Key
+		if s.Provider == SecretProviderKeyring && s.Key == "" {
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage(`secret from keyring %q must have a "key"`, s.Source)
+		}
+		return nil
+	}
+
+	return errors.NewTaskfileDecodeError(nil, node).WithTypeMessage("secret")
+}
@@ -82,14 +82,22 @@ type Task struct {
 	IgnoreError   bool
 	Run           string
 	Platforms     []*Platform
+	Container     *Container
+	Interpreter   string
+	Hosts         []string
+	Uses          []string
+	Log           *TaskLog
 	Watch         bool
 	Location      *Location
 	Failfast      bool
 	Index         OptionalInt // Optional index for ordering tasks within categories
+	// Strict is set for the tasks of Taskfiles that enable strict templates
+	Strict bool `hash:"ignore"`
 	// Populated during merging
-	Namespace            string `hash:"ignore"`
-	IncludeVars          *Vars
-	IncludedTaskfileVars *Vars
+	Namespace              string `hash:"ignore"`
+	IncludeVars            *Vars
+	IncludedTaskfileVars   *Vars
+	IncludedTaskfileDotenv []string
 
 	FullName string `hash:"ignore"`
 }
@@ -186,6 +194,12 @@ func (t *Task) UnmarshalYAML(node *yaml.Node) error {
 			IgnoreError   bool `yaml:"ignore_error"`
 			Run           string
 			Platforms     []*Platform
+			Container     *Container
+			Interpreter   string
+			Host          string
+			Hosts         []string
+			Uses          []string
+			Log           *TaskLog
 			Requires      *Requires
 			Watch         bool
 			Failfast      bool
@@ -194,6 +208,19 @@ func (t *Task) UnmarshalYAML(node *yaml.Node) error {
 		if err := node.Decode(&task); err != nil {
 			return errors.NewTaskfileDecodeError(err, node)
 		}
+		if task.Host != "" {
+			if task.Hosts != nil {
+				return errors.NewTaskfileDecodeError(nil, node).WithMessage("task cannot have both host and hosts")
+			}
+			task.Hosts = []string{task.Host}
+		}
+		if task.Requires != nil {
+			for _, v := range task.Requires.Vars {
+				if v != nil && v.Default != nil {
+					return errors.NewTaskfileDecodeError(nil, node).WithMessage(`"default" is only supported by the requires of a Taskfile`)
+				}
+			}
+		}
 		if task.Cmd != nil {
 			if task.Cmds != nil {
 				return errors.NewTaskfileDecodeError(nil, node).WithMessage("task cannot have both cmd and cmds")
@@ -227,6 +254,11 @@ func (t *Task) UnmarshalYAML(node *yaml.Node) error {
 		t.IgnoreError = task.IgnoreError
 		t.Run = task.Run
 		t.Platforms = task.Platforms
+		t.Container = task.Container
+		t.Interpreter = task.Interpreter
+		t.Hosts = task.Hosts
+		t.Uses = task.Uses
+		t.Log = task.Log
 		t.Requires = task.Requires
 		t.Watch = task.Watch
 		t.Failfast = task.Failfast
@@ -244,41 +276,48 @@ func (t *Task) DeepCopy() *Task {
 		return nil
 	}
 	c := &Task{
-		Task:                 t.Task,
-		Cmds:                 deepcopy.Slice(t.Cmds),
-		Deps:                 deepcopy.Slice(t.Deps),
-		Label:                t.Label,
-		Desc:                 t.Desc,
-		Prompt:               t.Prompt,
-		Summary:              t.Summary,
-		Category:             t.Category,
-		Aliases:              deepcopy.Slice(t.Aliases),
-		Sources:              deepcopy.Slice(t.Sources),
-		Generates:            deepcopy.Slice(t.Generates),
-		Status:               deepcopy.Slice(t.Status),
-		Preconditions:        deepcopy.Slice(t.Preconditions),
-		Dir:                  t.Dir,
-		Set:                  deepcopy.Slice(t.Set),
-		Shopt:                deepcopy.Slice(t.Shopt),
-		Vars:                 t.Vars.DeepCopy(),
-		Env:                  t.Env.DeepCopy(),
-		Dotenv:               deepcopy.Slice(t.Dotenv),
-		Silent:               t.Silent,
-		Interactive:          t.Interactive,
-		Internal:             t.Internal,
-		Method:               t.Method,
-		Prefix:               t.Prefix,
-		IgnoreError:          t.IgnoreError,
-		Run:                  t.Run,
-		IncludeVars:          t.IncludeVars.DeepCopy(),
-		IncludedTaskfileVars: t.IncludedTaskfileVars.DeepCopy(),
-		Platforms:            deepcopy.Slice(t.Platforms),
-		Location:             t.Location.DeepCopy(),
-		Requires:             t.Requires.DeepCopy(),
-		Namespace:            t.Namespace,
-		FullName:             t.FullName,
-		Failfast:             t.Failfast,
-		Index:                t.Index,
+		Task:                   t.Task,
+		Cmds:                   deepcopy.Slice(t.Cmds),
+		Deps:                   deepcopy.Slice(t.Deps),
+		Label:                  t.Label,
+		Desc:                   t.Desc,
+		Prompt:                 t.Prompt,
+		Summary:                t.Summary,
+		Category:               t.Category,
+		Aliases:                deepcopy.Slice(t.Aliases),
+		Sources:                deepcopy.Slice(t.Sources),
+		Generates:              deepcopy.Slice(t.Generates),
+		Status:                 deepcopy.Slice(t.Status),
+		Preconditions:          deepcopy.Slice(t.Preconditions),
+		Dir:                    t.Dir,
+		Set:                    deepcopy.Slice(t.Set),
+		Shopt:                  deepcopy.Slice(t.Shopt),
+		Vars:                   t.Vars.DeepCopy(),
+		Env:                    t.Env.DeepCopy(),
+		Dotenv:                 deepcopy.Slice(t.Dotenv),
+		Silent:                 t.Silent,
+		Interactive:            t.Interactive,
+		Internal:               t.Internal,
+		Method:                 t.Method,
+		Prefix:                 t.Prefix,
+		IgnoreError:            t.IgnoreError,
+		Run:                    t.Run,
+		IncludeVars:            t.IncludeVars.DeepCopy(),
+		IncludedTaskfileVars:   t.IncludedTaskfileVars.DeepCopy(),
+		IncludedTaskfileDotenv: deepcopy.Slice(t.IncludedTaskfileDotenv),
+		Platforms:              deepcopy.Slice(t.Platforms),
+		Container:              t.Container.DeepCopy(),
+		Interpreter:            t.Interpreter,
+		Hosts:                  deepcopy.Slice(t.Hosts),
+		Uses:                   deepcopy.Slice(t.Uses),
+		Log:                    t.Log.DeepCopy(),
+		Location:               t.Location.DeepCopy(),
+		Requires:               t.Requires.DeepCopy(),
+		Namespace:              t.Namespace,
+		FullName:               t.FullName,
+		Failfast:               t.Failfast,
+		Index:                  t.Index,
+		Strict:                 t.Strict,
 	}
 	return c
 }
@@ -0,0 +1,49 @@
+package ast
+
+import (
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// TaskLog configures the file that the output of a task is saved to
+type TaskLog struct {
+	// Enabled is false for tasks that opt out of --log-dir
+	Enabled bool
+	// Dir is the directory the log files are written to. It defaults to the
+	// directory set with --log-dir, or to .task/logs.
+	Dir string
+}
+
+func (l *TaskLog) DeepCopy() *TaskLog {
+	if l == nil {
+		return nil
+	}
+	return &TaskLog{
+		Enabled: l.Enabled,
+		Dir:     l.Dir,
+	}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (l *TaskLog) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!bool" {
+			var enabled bool
+			if err := node.Decode(&enabled); err != nil {
+				return errors.NewTaskfileDecodeError(err, node)
+			}
+			l.Enabled = enabled
+			return nil
+		}
+		var dir string
+		if err := node.Decode(&dir); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		l.Enabled = true
+		l.Dir = dir
+		return nil
+	}
+
+	return errors.NewTaskfileDecodeError(nil, node).WithTypeMessage("log")
+}
@@ -2,12 +2,16 @@ package ast
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"go.yaml.in/yaml/v4"
 
 	"github.com/vikbert/taskr/v3/errors"
+	"github.com/vikbert/taskr/v3/internal/filepathext"
 )
 
 // NamespaceSeparator contains the character that separates namespaces
@@ -15,9 +19,6 @@ const NamespaceSeparator = ":"
 
 var V3 = semver.MustParse("3")
 
-// ErrIncludedTaskfilesCantHaveDotenvs is returned when a included Taskfile contains dotenvs
-var ErrIncludedTaskfilesCantHaveDotenvs = errors.New("task: Included Taskfiles can't have dotenv declarations. Please, move the dotenv declaration to the main Taskfile")
-
 // Taskfile is the abstract syntax tree for a Taskfile
 type Taskfile struct {
 	Location   string
@@ -37,6 +38,19 @@ type Taskfile struct {
 	Interval   time.Duration
 	Banner     bool
 	Categories []string
+	Resources  map[string]int
+	// Requires are the variables that must be passed to the Taskfile when it
+	// is included.
+	Requires *Requires
+	// Exports are the tasks that can be called when the Taskfile is included.
+	// If set, every other task is internal.
+	Exports []string
+	// Strict makes the templates of the tasks of the Taskfile fail when they
+	// use undefined variables.
+	Strict bool
+	// Profiles are the named sets of variables that can be selected with the
+	// --profile flag.
+	Profiles map[string]*Profile
 }
 
 // Merge merges the second Taskfile into the first
@@ -44,8 +58,14 @@ func (t1 *Taskfile) Merge(t2 *Taskfile, include *Include) error {
 	if !t1.Version.Equal(t2.Version) {
 		return fmt.Errorf(`task: Taskfiles versions should match. First is "%s" but second is "%s"`, t1.Version, t2.Version)
 	}
-	if len(t2.Dotenv) > 0 {
-		return ErrIncludedTaskfilesCantHaveDotenvs
+	include, err := t2.checkRequires(include)
+	if err != nil {
+		return err
+	}
+	for _, name := range t2.Exports {
+		if _, ok := t2.Tasks.Get(name); !ok {
+			return fmt.Errorf(`task: Task %q exported by %q does not exist`, name, t2.Location)
+		}
 	}
 	if t2.Output.IsSet() {
 		t1.Output = t2.Output
@@ -62,9 +82,136 @@ func (t1 *Taskfile) Merge(t2 *Taskfile, include *Include) error {
 	if t1.Tasks == nil {
 		t1.Tasks = NewTasks()
 	}
+	for name, capacity := range t2.Resources {
+		if existing, ok := t1.Resources[name]; ok && existing != capacity {
+			return fmt.Errorf(`task: Resource %q is declared with different capacities (%d and %d)`, name, existing, capacity)
+		}
+		if t1.Resources == nil {
+			t1.Resources = map[string]int{}
+		}
+		t1.Resources[name] = capacity
+	}
+	for name, profile := range t2.Profiles {
+		if t1.Profiles == nil {
+			t1.Profiles = map[string]*Profile{}
+		}
+		if t1.Profiles[name] == nil {
+			t1.Profiles[name] = &Profile{}
+		}
+		t1.Profiles[name].Merge(profile, include)
+	}
 	t1.Vars.Merge(t2.Vars, include)
 	t1.Env.Merge(t2.Env, include)
-	return t1.Tasks.Merge(t2.Tasks, include, t1.Vars)
+	return t1.Tasks.Merge(t2.Tasks, include, t1.Vars, t2.Exports, t2.dotenvPaths())
+}
+
+// SetVarsTaskfile sets the location of the Taskfile as the Taskfile of the
+// locations of the variables declared in it.
+func (tf *Taskfile) SetVarsTaskfile() {
+	tf.Vars.SetTaskfile(tf.Location)
+	tf.Env.SetTaskfile(tf.Location)
+	for _, profile := range tf.Profiles {
+		if profile != nil {
+			profile.Vars.SetTaskfile(tf.Location)
+			profile.Env.SetTaskfile(tf.Location)
+		}
+	}
+	for include := range tf.Includes.Values() {
+		if include != nil {
+			include.Vars.SetTaskfile(tf.Location)
+		}
+	}
+	for task := range tf.Tasks.Values(nil) {
+		if task == nil {
+			continue
+		}
+		task.Vars.SetTaskfile(tf.Location)
+		task.Env.SetTaskfile(tf.Location)
+		for _, cmd := range task.Cmds {
+			if cmd != nil {
+				cmd.Vars.SetTaskfile(tf.Location)
+			}
+		}
+		for _, dep := range task.Deps {
+			if dep != nil {
+				dep.Vars.SetTaskfile(tf.Location)
+			}
+		}
+	}
+}
+
+// dotenvPaths returns the dotenv files of the Taskfile relative to its
+// directory. The location of remote Taskfiles is not a directory, so their
+// paths are left as is.
+func (tf *Taskfile) dotenvPaths() []string {
+	paths := make([]string, 0, len(tf.Dotenv))
+	for _, path := range tf.Dotenv {
+		if filepath.IsAbs(tf.Location) {
+			path = filepathext.SmartJoin(filepath.Dir(tf.Location), path)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// checkRequires checks that the include passes every variable required by the
+// Taskfile with an allowed value. It returns the include with the default value
+// of the missing variables added to a copy of its variables, so that the
+// include of the graph is left as is.
+func (tf *Taskfile) checkRequires(include *Include) (*Include, error) {
+	if tf.Requires == nil || len(tf.Requires.Vars) == 0 {
+		return include, nil
+	}
+
+	var copied bool
+	var missingVars []errors.MissingVar
+	var notAllowedVars []errors.NotAllowedVar
+	for _, requiredVar := range tf.Requires.Vars {
+		v, ok := include.Vars.Get(requiredVar.Name)
+		if !ok && requiredVar.Default != nil {
+			if !copied {
+				include = include.DeepCopy()
+				if include.Vars == nil {
+					include.Vars = NewVars()
+				}
+				copied = true
+			}
+			include.Vars.Set(requiredVar.Name, Var{Value: requiredVar.Default})
+			continue
+		}
+		if !ok {
+			missingVars = append(missingVars, errors.MissingVar{
+				Name:          requiredVar.Name,
+				AllowedValues: requiredVar.Enum,
+			})
+			continue
+		}
+		// Values that are templated can only be checked when the task runs
+		value, isString := v.Value.(string)
+		if isString && requiredVar.Enum != nil && !strings.Contains(value, "{{") && !slices.Contains(requiredVar.Enum, value) {
+			notAllowedVars = append(notAllowedVars, errors.NotAllowedVar{
+				Value: value,
+				Enum:  requiredVar.Enum,
+				Name:  requiredVar.Name,
+			})
+		}
+	}
+
+	if len(missingVars) > 0 {
+		return nil, &errors.IncludeMissingRequiredVarsError{
+			URI:         tf.Location,
+			Namespace:   include.Namespace,
+			MissingVars: missingVars,
+		}
+	}
+	if len(notAllowedVars) > 0 {
+		return nil, &errors.IncludeNotAllowedVarsError{
+			URI:            tf.Location,
+			Namespace:      include.Namespace,
+			NotAllowedVars: notAllowedVars,
+		}
+	}
+	return include, nil
 }
 
 func (tf *Taskfile) UnmarshalYAML(node *yaml.Node) error {
@@ -87,6 +234,11 @@ func (tf *Taskfile) UnmarshalYAML(node *yaml.Node) error {
 			Interval   time.Duration
 			Banner     bool
 			Categories []string
+			Resources  map[string]int
+			Requires   *Requires
+			Exports    []string
+			Strict     bool
+			Profiles   map[string]*Profile
 		}
 		if err := node.Decode(&taskfile); err != nil {
 			return errors.NewTaskfileDecodeError(err, node)
@@ -107,6 +259,16 @@ func (tf *Taskfile) UnmarshalYAML(node *yaml.Node) error {
 		tf.Interval = taskfile.Interval
 		tf.Banner = taskfile.Banner
 		tf.Categories = taskfile.Categories
+		tf.Resources = taskfile.Resources
+		tf.Requires = taskfile.Requires
+		tf.Exports = taskfile.Exports
+		tf.Strict = taskfile.Strict
+		tf.Profiles = taskfile.Profiles
+		for name, capacity := range tf.Resources {
+			if capacity < 1 {
+				return errors.NewTaskfileDecodeError(nil, node).WithMessage("resource %q must have a capacity of at least 1", name)
+			}
+		}
 		if tf.Includes == nil {
 			tf.Includes = NewIncludes()
 		}
@@ -119,6 +281,13 @@ func (tf *Taskfile) UnmarshalYAML(node *yaml.Node) error {
 		if tf.Tasks == nil {
 			tf.Tasks = NewTasks()
 		}
+		if tf.Strict {
+			for task := range tf.Tasks.Values(nil) {
+				if task != nil {
+					task.Strict = true
+				}
+			}
+		}
 		return nil
 	}
 
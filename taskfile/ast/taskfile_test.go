@@ -44,13 +44,15 @@ vars:
 					&ast.VarElement{
 						Key: "PARAM1",
 						Value: ast.Var{
-							Value: "VALUE1",
+							Value:    "VALUE1",
+							Location: &ast.Location{Line: 4, Column: 3},
 						},
 					},
 					&ast.VarElement{
 						Key: "PARAM2",
 						Value: ast.Var{
-							Value: "VALUE2",
+							Value:    "VALUE2",
+							Location: &ast.Location{Line: 5, Column: 3},
 						},
 					},
 				),
@@ -70,7 +72,8 @@ vars:
 					&ast.VarElement{
 						Key: "PARAM1",
 						Value: ast.Var{
-							Value: "var",
+							Value:    "var",
+							Location: &ast.Location{Line: 1, Column: 35},
 						},
 					},
 				),
@@ -91,13 +94,15 @@ vars:
 					&ast.VarElement{
 						Key: "PARAM1",
 						Value: ast.Var{
-							Value: "VALUE1",
+							Value:    "VALUE1",
+							Location: &ast.Location{Line: 4, Column: 3},
 						},
 					},
 					&ast.VarElement{
 						Key: "PARAM2",
 						Value: ast.Var{
-							Value: "VALUE2",
+							Value:    "VALUE2",
+							Location: &ast.Location{Line: 5, Column: 3},
 						},
 					},
 				),
@@ -110,3 +115,38 @@ vars:
 		assert.Equal(t, test.expected, test.v)
 	}
 }
+
+func TestMergeRequiresDefault(t *testing.T) {
+	t.Parallel()
+
+	t1 := &ast.Taskfile{Version: ast.V3}
+	t2 := &ast.Taskfile{
+		Version: ast.V3,
+		Tasks:   ast.NewTasks(),
+		Requires: &ast.Requires{
+			Vars: []*ast.VarsWithValidation{{Name: "REGION", Default: "eu"}},
+		},
+	}
+	include := &ast.Include{Namespace: "lib"}
+	require.NoError(t, t1.Merge(t2, include))
+
+	// The default is applied without changing the include of the graph
+	assert.Nil(t, include.Vars)
+}
+
+func TestTaskRequiresDefault(t *testing.T) {
+	t.Parallel()
+
+	const yamlTask = `
+requires:
+  vars:
+    - name: REGION
+      default: eu
+cmds:
+  - echo {{.REGION}}
+`
+	var task ast.Task
+	err := yaml.Unmarshal([]byte(yamlTask), &task)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"default" is only supported by the requires of a Taskfile`)
+}
@@ -118,7 +118,7 @@ func (t *Tasks) Values(sorter sort.Sorter) iter.Seq[*Task] {
 	}
 }
 
-func (t1 *Tasks) Merge(t2 *Tasks, include *Include, includedTaskfileVars *Vars) error {
+func (t1 *Tasks) Merge(t2 *Tasks, include *Include, includedTaskfileVars *Vars, exports []string, dotenv []string) error {
 	defer t2.mutex.RUnlock()
 	t2.mutex.RLock()
 	for name, v := range t2.All(nil) {
@@ -128,6 +128,13 @@ func (t1 *Tasks) Merge(t2 *Tasks, include *Include, includedTaskfileVars *Vars)
 		// Set the task to internal if EITHER the included task or the included
 		// taskfile are marked as internal
 		task.Internal = task.Internal || (include != nil && include.Internal)
+		// The dotenv files of the included Taskfile only apply to its own
+		// tasks. Those of Taskfiles included further down take precedence.
+		task.IncludedTaskfileDotenv = slices.Concat(task.IncludedTaskfileDotenv, dotenv)
+		// Set the task to internal if the included Taskfile exports other tasks
+		if len(exports) > 0 && !slices.Contains(exports, name) {
+			task.Internal = true
+		}
 		taskName := name
 
 		// if the task is in the exclude list, don't add it to the merged taskfile
@@ -177,6 +184,13 @@ func (t1 *Tasks) Merge(t2 *Tasks, include *Include, includedTaskfileVars *Vars)
 			}
 			task.IncludeVars.Merge(include.Vars, nil)
 			task.IncludedTaskfileVars = includedTaskfileVars.DeepCopy()
+		} else if include.Vars.Len() > 0 {
+			// Simple includes can only have the default values of the
+			// variables required by the included Taskfile
+			if task.IncludeVars == nil {
+				task.IncludeVars = NewVars()
+			}
+			task.IncludeVars.Merge(include.Vars, nil)
 		}
 
 		if _, ok := t1.Get(taskName); ok {
@@ -11,8 +11,18 @@ type Var struct {
 	Value any
 	Live  any
 	Sh    *string
+	// Cache is set for dynamic variables whose result is cached between runs
+	Cache *VarCache
 	Ref   string
-	Dir   string
+	// Secret is set for variables whose value is read from a secret provider
+	Secret *Secret
+	Dir    string
+	// Location is where the variable is declared. It is nil for variables that
+	// are not declared in a Taskfile, e.g. those passed on the command line.
+	Location *Location
+	// Shadowed is the variable that was replaced when the variable overrode it
+	// with [Vars.Override]. It is only used to explain where values come from.
+	Shadowed *Var
 }
 
 func (v *Var) UnmarshalYAML(node *yaml.Node) error {
@@ -23,21 +33,28 @@ func (v *Var) UnmarshalYAML(node *yaml.Node) error {
 			key = node.Content[0].Value
 		}
 		switch key {
-		case "sh", "ref", "map":
+		case "sh", "ref", "map", "secret", "cache":
 			var m struct {
-				Sh  *string
-				Ref string
-				Map any
+				Sh     *string
+				Cache  *VarCache
+				Ref    string
+				Map    any
+				Secret *Secret
 			}
 			if err := node.Decode(&m); err != nil {
 				return errors.NewTaskfileDecodeError(err, node)
 			}
+			if m.Cache != nil && m.Sh == nil {
+				return errors.NewTaskfileDecodeError(nil, node).WithMessage(`"cache" is only supported by dynamic variables`)
+			}
 			v.Sh = m.Sh
+			v.Cache = m.Cache
 			v.Ref = m.Ref
 			v.Value = m.Map
+			v.Secret = m.Secret
 			return nil
 		default:
-			return errors.NewTaskfileDecodeError(nil, node).WithMessage(`%q is not a valid variable type. Try "sh", "ref", "map" or using a scalar value`, key)
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage(`%q is not a valid variable type. Try "sh", "ref", "map", "secret" or using a scalar value`, key)
 		}
 	default:
 		var value any
@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"time"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// VarCache configures how long the result of a dynamic variable is cached
+// between runs
+type VarCache struct {
+	// TTL is how long the result is valid for
+	TTL time.Duration
+	// Sources are the files whose changes invalidate the result
+	Sources []*Glob
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (c *VarCache) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		var cache struct {
+			TTL     time.Duration
+			Sources []*Glob
+		}
+		if err := node.Decode(&cache); err != nil {
+			return errors.NewTaskfileDecodeError(err, node)
+		}
+		if cache.TTL < 0 {
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage("cache ttl must not be negative")
+		}
+		if cache.TTL == 0 && len(cache.Sources) == 0 {
+			return errors.NewTaskfileDecodeError(nil, node).WithMessage(`cache must have a "ttl" or "sources"`)
+		}
+		c.TTL = cache.TTL
+		c.Sources = cache.Sources
+		return nil
+	}
+
+	return errors.NewTaskfileDecodeError(nil, node).WithTypeMessage("cache")
+}
@@ -133,6 +133,25 @@ func (vars *Vars) Merge(other *Vars, include *Include) {
 	}
 }
 
+// Override merges other into vars like [Vars.Merge], but keeps the variables
+// that are replaced as the shadowed variables of the new ones.
+func (vars *Vars) Override(other *Vars) {
+	if vars == nil || vars.om == nil || other == nil {
+		return
+	}
+	defer other.mutex.RUnlock()
+	other.mutex.RLock()
+	defer vars.mutex.Unlock()
+	vars.mutex.Lock()
+	for pair := other.om.Front(); pair != nil; pair = pair.Next() {
+		v := pair.Value
+		if existing, ok := vars.om.Get(pair.Key); ok {
+			v.Shadowed = &existing
+		}
+		vars.om.Set(pair.Key, v)
+	}
+}
+
 // ReverseMerge merges other variables with the existing variables in vars, but
 // keeps the other variables first in order. If the include parameter is not
 // nil and it is an advanced import, the directory is set to the value of the
@@ -162,6 +181,21 @@ func (vars *Vars) ReverseMerge(other *Vars, include *Include) {
 	vars.mutex.Unlock()
 }
 
+// SetTaskfile sets the Taskfile of the locations of the variables that don't
+// have one yet.
+func (vars *Vars) SetTaskfile(taskfile string) {
+	if vars == nil || vars.om == nil {
+		return
+	}
+	defer vars.mutex.RUnlock()
+	vars.mutex.RLock()
+	for v := range vars.om.Values() {
+		if v.Location != nil && v.Location.Taskfile == "" {
+			v.Location.Taskfile = taskfile
+		}
+	}
+}
+
 func (vs *Vars) DeepCopy() *Vars {
 	if vs == nil {
 		return nil
@@ -192,6 +226,10 @@ func (vs *Vars) UnmarshalYAML(node *yaml.Node) error {
 			if err := valueNode.Decode(&v); err != nil {
 				return errors.NewTaskfileDecodeError(err, node)
 			}
+			v.Location = &Location{
+				Line:   keyNode.Line,
+				Column: keyNode.Column,
+			}
 
 			// Add the task to the ordered map
 			vs.Set(keyNode.Value, v)
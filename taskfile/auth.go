@@ -0,0 +1,234 @@
+package taskfile
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/vikbert/taskr/v3/internal/execext"
+)
+
+// defaultGitUsername is the username sent along with a token when cloning a
+// Git repository over HTTPS. Most Git hosts accept any username with a token.
+const defaultGitUsername = "oauth2"
+
+// An Auth configures how requests to a host serving remote Taskfiles are
+// authenticated. Only one method should be set. Tokens are sent as bearer
+// tokens to HTTP hosts and as the password of basic auth to Git hosts.
+type Auth struct {
+	// TokenEnv is the environment variable that holds a token.
+	TokenEnv string
+	// Username and PasswordEnv configure basic auth. PasswordEnv is the
+	// environment variable that holds the password.
+	Username    string
+	PasswordEnv string
+	// Netrc reads the credentials of the host from the netrc file given by
+	// the NETRC environment variable, or ~/.netrc by default.
+	Netrc bool
+	// CredentialHelper is a command whose output is used as a token.
+	CredentialHelper string
+
+	mutex       sync.Mutex
+	credentials *credentials
+	resolved    bool
+}
+
+// credentials are the resolved secrets of an [Auth].
+type credentials struct {
+	username string
+	password string
+	token    string
+}
+
+// authFor returns the auth configured for the host of the given URL, if any.
+func authFor(auth map[string]*Auth, u *url.URL) *Auth {
+	if a, ok := auth[u.Host]; ok {
+		return a
+	}
+	return auth[u.Hostname()]
+}
+
+// resolve returns the credentials of the auth. They are kept once resolved,
+// so that credential helpers do not run for every request. Failures are not
+// kept, since they may come from a cancelled request or a transient error.
+func (a *Auth) resolve(ctx context.Context, host string) (*credentials, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.resolved {
+		return a.credentials, nil
+	}
+	credentials, err := a.read(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	a.credentials, a.resolved = credentials, true
+	return credentials, nil
+}
+
+func (a *Auth) read(ctx context.Context, host string) (*credentials, error) {
+	switch {
+	case a.TokenEnv != "":
+		token := os.Getenv(a.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("task: Environment variable %q for the token of host %q is not set", a.TokenEnv, host)
+		}
+		return &credentials{username: a.Username, token: token}, nil
+	case a.Username != "" || a.PasswordEnv != "":
+		return &credentials{username: a.Username, password: os.Getenv(a.PasswordEnv)}, nil
+	case a.Netrc:
+		return readNetrc(host)
+	case a.CredentialHelper != "":
+		var stdout, stderr bytes.Buffer
+		err := execext.RunCommand(ctx, &execext.RunCommandOptions{
+			Command: a.CredentialHelper,
+			Stdout:  &stdout,
+			Stderr:  &stderr,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("task: Credential helper for host %q failed: %v", host, err)
+		}
+		token := strings.TrimSpace(stdout.String())
+		if token == "" {
+			return nil, fmt.Errorf("task: Credential helper for host %q returned an empty token", host)
+		}
+		return &credentials{username: a.Username, token: token}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// method describes the authentication method for debug messages, without
+// revealing any secret.
+func (a *Auth) method() string {
+	switch {
+	case a.TokenEnv != "":
+		return fmt.Sprintf("token from $%s", a.TokenEnv)
+	case a.Username != "" || a.PasswordEnv != "":
+		return fmt.Sprintf("basic auth as %q", a.Username)
+	case a.Netrc:
+		return "netrc"
+	case a.CredentialHelper != "":
+		return "credential helper"
+	default:
+		return "no credentials"
+	}
+}
+
+// authorize adds the credentials of the auth configured for the host of the
+// request to the request.
+func authorize(ctx context.Context, auth map[string]*Auth, req *http.Request) error {
+	a := authFor(auth, req.URL)
+	if a == nil {
+		return nil
+	}
+	creds, err := a.resolve(ctx, req.URL.Host)
+	if err != nil || creds == nil {
+		return err
+	}
+	if creds.token != "" {
+		req.Header.Set("Authorization", "Bearer "+creds.token)
+	} else {
+		req.SetBasicAuth(creds.username, creds.password)
+	}
+	return nil
+}
+
+// userinfo returns the credentials of the auth configured for the host of the
+// given URL in the form used to clone Git repositories over HTTPS.
+func userinfo(ctx context.Context, auth map[string]*Auth, u *url.URL) (*url.Userinfo, []string, error) {
+	a := authFor(auth, u)
+	if a == nil {
+		return nil, nil, nil
+	}
+	creds, err := a.resolve(ctx, u.Host)
+	if err != nil || creds == nil {
+		return nil, nil, err
+	}
+	if creds.token != "" {
+		username := creds.username
+		if username == "" {
+			username = defaultGitUsername
+		}
+		return url.UserPassword(username, creds.token), []string{creds.token}, nil
+	}
+	return url.UserPassword(creds.username, creds.password), []string{creds.password}, nil
+}
+
+// redact replaces every given secret in s.
+func redact(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "xxxxx")
+			s = strings.ReplaceAll(s, url.QueryEscape(secret), "xxxxx")
+		}
+	}
+	return s
+}
+
+// readNetrc returns the credentials of the given host from the netrc file.
+func readNetrc(host string) (*credentials, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("task: Unable to read netrc file for host %q: %w", host, err)
+	}
+
+	hostname := host
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		hostname = h
+	}
+
+	var (
+		creds    *credentials
+		fallback *credentials
+		current  *credentials
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "machine":
+			current = &credentials{}
+			if scanner.Scan() && (scanner.Text() == host || scanner.Text() == hostname) && creds == nil {
+				creds = current
+			}
+		case "default":
+			current = &credentials{}
+			if fallback == nil {
+				fallback = current
+			}
+		case "login":
+			if scanner.Scan() && current != nil {
+				current.username = scanner.Text()
+			}
+		case "password":
+			if scanner.Scan() && current != nil {
+				current.password = scanner.Text()
+			}
+		case "macdef":
+			// Macros are not supported, so skip everything until the next machine
+			current = nil
+		}
+	}
+	if creds == nil {
+		creds = fallback
+	}
+	if creds == nil {
+		return nil, fmt.Errorf("task: No credentials found in netrc file for host %q", host)
+	}
+	return creds, nil
+}
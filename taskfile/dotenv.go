@@ -1,6 +1,7 @@
 package taskfile
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
@@ -11,31 +12,79 @@ import (
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 )
 
-func Dotenv(vars *ast.Vars, tf *ast.Taskfile, dir string) (*ast.Vars, error) {
-	env := ast.NewVars()
-	cache := &templater.Cache{Vars: vars}
+func Dotenv(vars *ast.Vars, tf *ast.Taskfile, dir, environment string) (*ast.Vars, error) {
+	cache := &templater.Cache{Vars: vars, Dir: dir}
 
+	paths := make([]string, 0, len(tf.Dotenv))
 	for _, dotEnvPath := range tf.Dotenv {
 		dotEnvPath = templater.Replace(dotEnvPath, cache)
 		if dotEnvPath == "" {
 			continue
 		}
-		dotEnvPath = filepathext.SmartJoin(dir, dotEnvPath)
+		paths = append(paths, filepathext.SmartJoin(dir, dotEnvPath))
+	}
 
-		if _, err := os.Stat(dotEnvPath); os.IsNotExist(err) {
-			continue
-		}
+	return ReadDotenvFiles(paths, environment)
+}
 
-		envs, err := godotenv.Read(dotEnvPath)
+// ReadDotenvFiles reads the given dotenv files. The variables of the files
+// listed first take precedence and files that don't exist are ignored.
+//
+// If an environment is given, each file is layered with its ".<environment>"
+// and ".<environment>.local" variants. The layers are read as if they were
+// sourced one after the other, so the variants override the variables of the
+// file and can reference them with ${VAR}.
+func ReadDotenvFiles(paths []string, environment string) (*ast.Vars, error) {
+	env := ast.NewVars()
+	for _, path := range paths {
+		envs, files, err := readDotenvLayers(path, environment)
 		if err != nil {
-			return nil, fmt.Errorf("error reading env file %s: %w", dotEnvPath, err)
+			return nil, err
 		}
 		for key, value := range envs {
 			if _, ok := env.Get(key); !ok {
-				env.Set(key, ast.Var{Value: value})
+				env.Set(key, ast.Var{
+					Value:    value,
+					Location: &ast.Location{Taskfile: files[key]},
+				})
 			}
 		}
 	}
-
 	return env, nil
 }
+
+// readDotenvLayers returns the variables of the layers of the given dotenv file
+// and, for each variable, the layer that sets its final value.
+func readDotenvLayers(path, environment string) (map[string]string, map[string]string, error) {
+	layers := []string{path}
+	if environment != "" {
+		layers = append(layers, path+"."+environment, path+"."+environment+".local")
+	}
+
+	var content bytes.Buffer
+	files := map[string]string{}
+	for _, layer := range layers {
+		b, err := os.ReadFile(layer)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading env file %s: %w", layer, err)
+		}
+		// Parse each layer on its own first, so that errors name the file
+		envs, err := godotenv.UnmarshalBytes(b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading env file %s: %w", layer, err)
+		}
+		for key := range envs {
+			files[key] = layer
+		}
+		content.Write(b)
+		content.WriteByte('\n')
+	}
+	if content.Len() == 0 {
+		return nil, nil, nil
+	}
+	envs, err := godotenv.UnmarshalBytes(content.Bytes())
+	return envs, files, err
+}
@@ -0,0 +1,57 @@
+package taskfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vikbert/taskr/v3/errors"
+	"github.com/vikbert/taskr/v3/internal/execext"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// expandGlobInclude expands an include whose namespace contains a wildcard
+// into an include for every Taskfile matched by its path. The wildcards of the
+// namespace and of the directory are replaced with the name of the directory
+// that contains each matched Taskfile.
+func expandGlobInclude(node Node, include *ast.Include) ([]*ast.Include, error) {
+	pattern, err := node.ResolveEntrypoint(include.Taskfile)
+	if err != nil {
+		return nil, err
+	}
+	if isRemoteEntrypoint(pattern) {
+		return nil, fmt.Errorf("task: Glob include %q is only supported for local Taskfiles", include.Namespace)
+	}
+
+	matches, err := execext.ExpandFields(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		if include.Optional {
+			return nil, nil
+		}
+		return nil, errors.TaskfileNotFoundError{URI: pattern}
+	}
+
+	includes := make([]*ast.Include, 0, len(matches))
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+		// Directories are included like any other include of a directory, so
+		// their name is used as is
+		name := filepath.Base(match)
+		if !info.IsDir() {
+			name = filepath.Base(filepath.Dir(match))
+		}
+		expanded := include.DeepCopy()
+		expanded.Namespace = strings.ReplaceAll(include.Namespace, "*", name)
+		expanded.Taskfile = match
+		expanded.Dir = strings.ReplaceAll(include.Dir, "*", name)
+		includes = append(includes, expanded)
+	}
+	return includes, nil
+}
@@ -0,0 +1,89 @@
+package taskfile
+
+import (
+	"os"
+	"sync"
+
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	// LockfileName is the name of the file that pins the remote Taskfiles of
+	// a project. It is stored next to the root Taskfile.
+	LockfileName = "Taskfile.lock"
+	// lockfileVersion is the version of the lockfile format.
+	lockfileVersion = 1
+)
+
+type (
+	// A Lockfile pins every remote Taskfile included by a project to an exact
+	// URL, commit and checksum so that the same Taskfiles are read on every
+	// run. Remote Taskfiles are keyed by their location, as written in the
+	// including Taskfile.
+	Lockfile struct {
+		Version int                      `yaml:"version"`
+		Remotes map[string]*LockedRemote `yaml:"remotes"`
+		mu      sync.Mutex
+	}
+	// A LockedRemote is the state of a remote Taskfile recorded in a
+	// [Lockfile].
+	LockedRemote struct {
+		// URL is the exact URL the Taskfile was downloaded from.
+		URL string `yaml:"url"`
+		// Commit is the SHA of the commit the Taskfile was read from. It is
+		// only set for Taskfiles read from Git repositories.
+		Commit   string `yaml:"commit,omitempty"`
+		Checksum string `yaml:"checksum"`
+	}
+)
+
+// NewLockfile returns an empty [Lockfile].
+func NewLockfile() *Lockfile {
+	return &Lockfile{
+		Version: lockfileVersion,
+		Remotes: map[string]*LockedRemote{},
+	}
+}
+
+// ReadLockfile reads the [Lockfile] at the given path.
+func ReadLockfile(path string) (*Lockfile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := NewLockfile()
+	if err := yaml.Unmarshal(b, lock); err != nil {
+		return nil, err
+	}
+	if lock.Remotes == nil {
+		lock.Remotes = map[string]*LockedRemote{}
+	}
+	return lock, nil
+}
+
+// Write writes the [Lockfile] to the given path.
+func (l *Lockfile) Write(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// Get returns the state recorded for the remote Taskfile at the given
+// location, if any.
+func (l *Lockfile) Get(location string) (*LockedRemote, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	remote, ok := l.Remotes[location]
+	return remote, ok
+}
+
+// Set records the state of the remote Taskfile at the given location.
+func (l *Lockfile) Set(location string, remote *LockedRemote) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Remotes[location] = remote
+}
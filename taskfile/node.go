@@ -26,7 +26,16 @@ type Node interface {
 type RemoteNode interface {
 	Node
 	ReadContext(ctx context.Context) ([]byte, error)
+	// ReadSignatureContext reads the detached signature published alongside
+	// the Taskfile.
+	ReadSignatureContext(ctx context.Context) ([]byte, error)
 	CacheKey() string
+	// Pin makes the node read the exact URL and commit recorded in a
+	// [Lockfile] instead of resolving them again.
+	Pin(remote *LockedRemote)
+	// Resolved returns the exact URL and commit that the node was last read
+	// from.
+	Resolved() *LockedRemote
 }
 
 func NewRootNode(
@@ -34,13 +43,14 @@ func NewRootNode(
 	dir string,
 	insecure bool,
 	timeout time.Duration,
+	opts ...NodeOption,
 ) (Node, error) {
 	dir = fsext.DefaultDir(entrypoint, dir)
 	// If the entrypoint is "-", we read from stdin
 	if entrypoint == "-" {
 		return NewStdinNode(dir)
 	}
-	return NewNode(entrypoint, dir, insecure)
+	return NewNode(entrypoint, dir, insecure, opts...)
 }
 
 func NewNode(
@@ -62,6 +72,8 @@ func NewNode(
 		node, err = NewGitNode(entrypoint, dir, insecure, opts...)
 	case "http", "https":
 		node, err = NewHTTPNode(entrypoint, dir, insecure, opts...)
+	case "oci":
+		node, err = NewOCINode(entrypoint, dir, insecure, opts...)
 	default:
 		node, err = NewFileNode(entrypoint, dir, opts...)
 	}
@@ -75,7 +87,7 @@ func NewNode(
 func isRemoteEntrypoint(entrypoint string) bool {
 	scheme, _ := getScheme(entrypoint)
 	switch scheme {
-	case "git", "http", "https":
+	case "git", "http", "https", "oci":
 		return true
 	default:
 		return false
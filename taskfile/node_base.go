@@ -10,6 +10,7 @@ type (
 		parent   Node
 		dir      string
 		checksum string
+		auth     map[string]*Auth
 	}
 )
 
@@ -39,6 +40,14 @@ func WithChecksum(checksum string) NodeOption {
 	}
 }
 
+// WithHostAuth sets the auth used to authenticate requests to the hosts of
+// remote nodes, keyed by host.
+func WithHostAuth(auth map[string]*Auth) NodeOption {
+	return func(node *baseNode) {
+		node.auth = auth
+	}
+}
+
 func (node *baseNode) Parent() Node {
 	return node.parent
 }
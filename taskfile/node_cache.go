@@ -5,11 +5,74 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
 const remoteCacheDir = "remote"
 
+// A CacheEntry is a remote Taskfile stored in the cache.
+type CacheEntry struct {
+	dir string
+	// Key is the cache key of the remote Taskfile.
+	Key string
+	// Source is the location the Taskfile was downloaded from. It is empty
+	// for entries written by versions of Task that did not record it.
+	Source    string
+	Timestamp time.Time
+	Checksum  string
+	Size      int64
+}
+
+// ReadCacheEntries returns every remote Taskfile stored in the cache in the
+// given directory, sorted by source.
+func ReadCacheEntries(dir string) ([]*CacheEntry, error) {
+	dir = filepath.Join(dir, remoteCacheDir)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*CacheEntry
+	for _, file := range files {
+		key, ok := strings.CutSuffix(file.Name(), ".yaml")
+		if !ok || file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		entry := &CacheEntry{dir: dir, Key: key, Size: info.Size()}
+		entry.Source = readCacheFile(dir, key, "source")
+		entry.Checksum = readCacheFile(dir, key, "checksum")
+		entry.Timestamp = parseTimestamp(readCacheFile(dir, key, "timestamp"))
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *CacheEntry) int {
+		return strings.Compare(a.Source+a.Key, b.Source+b.Key)
+	})
+	return entries, nil
+}
+
+// Read returns the cached content of the remote Taskfile.
+func (entry *CacheEntry) Read() ([]byte, error) {
+	return os.ReadFile(cacheFilePath(entry.dir, entry.Key, "yaml"))
+}
+
+// Remove deletes the remote Taskfile and its metadata from the cache.
+func (entry *CacheEntry) Remove() error {
+	for _, suffix := range []string{"yaml", "source", "checksum", "timestamp", "minisig"} {
+		if err := os.Remove(cacheFilePath(entry.dir, entry.Key, suffix)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 type CacheNode struct {
 	*baseNode
 	source RemoteNode
@@ -40,11 +103,7 @@ func (node *CacheNode) ReadTimestamp() time.Time {
 	if err != nil {
 		return time.Time{}.UTC()
 	}
-	timestamp, err := time.Parse(time.RFC3339, string(b))
-	if err != nil {
-		return time.Time{}.UTC()
-	}
-	return timestamp.UTC()
+	return parseTimestamp(string(b))
 }
 
 func (node *CacheNode) WriteTimestamp(t time.Time) error {
@@ -66,6 +125,25 @@ func (node *CacheNode) WriteChecksum(checksum string) error {
 	return os.WriteFile(node.checksumPath(), []byte(checksum), 0o644)
 }
 
+func (node *CacheNode) ReadSignature() []byte {
+	b, _ := os.ReadFile(node.filePath("minisig"))
+	return b
+}
+
+func (node *CacheNode) WriteSignature(signature []byte) error {
+	if err := node.CreateCacheDir(); err != nil {
+		return err
+	}
+	return os.WriteFile(node.filePath("minisig"), signature, 0o644)
+}
+
+func (node *CacheNode) WriteSource() error {
+	if err := node.CreateCacheDir(); err != nil {
+		return err
+	}
+	return os.WriteFile(node.filePath("source"), []byte(node.source.Location()), 0o644)
+}
+
 func (node *CacheNode) CreateCacheDir() error {
 	if err := os.MkdirAll(node.dir, 0o755); err != nil {
 		return err
@@ -103,7 +181,24 @@ func (node *CacheNode) timestampPath() string {
 }
 
 func (node *CacheNode) filePath(suffix string) string {
-	return filepath.Join(node.dir, fmt.Sprintf("%s.%s", node.source.CacheKey(), suffix))
+	return cacheFilePath(node.dir, node.source.CacheKey(), suffix)
+}
+
+func cacheFilePath(dir, key, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.%s", key, suffix))
+}
+
+func readCacheFile(dir, key, suffix string) string {
+	b, _ := os.ReadFile(cacheFilePath(dir, key, suffix))
+	return string(b)
+}
+
+func parseTimestamp(s string) time.Time {
+	timestamp, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}.UTC()
+	}
+	return timestamp.UTC()
 }
 
 func checksum(b []byte) string {
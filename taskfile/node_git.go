@@ -1,10 +1,12 @@
 package taskfile
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -24,6 +26,10 @@ type GitNode struct {
 	rawUrl string
 	ref    string
 	path   string
+	// commit is the exact commit to read, as recorded in a lockfile
+	commit string
+	// resolvedCommit is the commit the file was last read from
+	resolvedCommit string
 }
 
 type gitRepoCache struct {
@@ -100,9 +106,28 @@ func (node *GitNode) Read() ([]byte, error) {
 	return node.ReadContext(context.Background())
 }
 
-func (node *GitNode) buildURL() string {
-	// Get the base URL
-	baseURL := node.url.String()
+// buildURL returns the URL of the repository for go-getter along with the
+// secrets it contains, which must be redacted from errors.
+func (node *GitNode) buildURL(ctx context.Context) (string, []string, error) {
+	// Get the base URL, with the configured credentials for HTTPS remotes
+	u := *node.url
+	var secrets []string
+	if u.Scheme == "https" || u.Scheme == "http" {
+		user, userSecrets, err := userinfo(ctx, node.auth, &u)
+		if err != nil {
+			return "", nil, err
+		}
+		if user != nil {
+			u.User = user
+			secrets = userSecrets
+		}
+	}
+	baseURL := u.String()
+
+	// Shallow clones can only check out branches and tags
+	if node.commit != "" {
+		return fmt.Sprintf("git::%s?ref=%s", baseURL, node.commit), secrets, nil
+	}
 
 	ref := node.ref
 	if ref == "" {
@@ -110,7 +135,7 @@ func (node *GitNode) buildURL() string {
 	}
 	// Always use git:: prefix for git URLs (following Terraform's pattern)
 	// This forces go-getter to use git protocol
-	return fmt.Sprintf("git::%s?ref=%s&depth=1", baseURL, ref)
+	return fmt.Sprintf("git::%s?ref=%s&depth=1", baseURL, ref), secrets, nil
 }
 
 // getOrCloneRepo returns the path to a cached git repository.
@@ -139,7 +164,10 @@ func (node *GitNode) getOrCloneRepo(ctx context.Context) (string, error) {
 		return cacheDir, nil
 	}
 
-	getterURL := node.buildURL()
+	getterURL, secrets, err := node.buildURL(ctx)
+	if err != nil {
+		return "", err
+	}
 
 	client := &getter.Client{
 		Ctx:  ctx,
@@ -150,6 +178,9 @@ func (node *GitNode) getOrCloneRepo(ctx context.Context) (string, error) {
 
 	if err := client.Get(); err != nil {
 		_ = os.RemoveAll(cacheDir)
+		if len(secrets) > 0 {
+			return "", fmt.Errorf("failed to clone repository: %s", redact(err.Error(), secrets...))
+		}
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
 
@@ -162,13 +193,7 @@ func (node *GitNode) ReadContext(ctx context.Context) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	// Build path to Taskfile in the cached repo
-	taskfilePath := node.path
-	if taskfilePath == "" {
-		taskfilePath = "Taskfile.yml"
-	}
-	filePath := filepath.Join(repoDir, taskfilePath)
+	filePath := filepath.Join(repoDir, node.taskfilePath())
 
 	// Read file from cached repo
 	b, err := os.ReadFile(filePath)
@@ -176,9 +201,43 @@ func (node *GitNode) ReadContext(ctx context.Context) ([]byte, error) {
 		return nil, err
 	}
 
+	// Record the commit the file was read from
+	out, err := exec.CommandContext(ctx, "git", "-C", repoDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	node.resolvedCommit = strings.TrimSpace(string(out))
+
 	return b, nil
 }
 
+func (node *GitNode) ReadSignatureContext(ctx context.Context) ([]byte, error) {
+	repoDir, err := node.getOrCloneRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(repoDir, node.taskfilePath()+signatureExtension))
+}
+
+// taskfilePath returns the path of the Taskfile in the repository.
+func (node *GitNode) taskfilePath() string {
+	if node.path == "" {
+		return "Taskfile.yml"
+	}
+	return node.path
+}
+
+func (node *GitNode) Pin(remote *LockedRemote) {
+	node.commit = remote.Commit
+}
+
+func (node *GitNode) Resolved() *LockedRemote {
+	return &LockedRemote{
+		URL:    node.url.Redacted(),
+		Commit: node.resolvedCommit,
+	}
+}
+
 func (node *GitNode) ResolveEntrypoint(entrypoint string) (string, error) {
 	// If the file is remote, we don't need to resolve the path
 	if isRemoteEntrypoint(entrypoint) {
@@ -228,7 +287,7 @@ func (node *GitNode) CacheKey() string {
 func (node *GitNode) repoCacheKey() string {
 	repoPath := strings.Trim(node.url.Path, "/")
 
-	ref := node.ref
+	ref := cmp.Or(node.commit, node.ref)
 	if ref == "" {
 		ref = "HEAD"
 	}
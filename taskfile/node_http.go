@@ -17,7 +17,9 @@ import (
 // An HTTPNode is a node that reads a Taskfile from a remote location via HTTP.
 type HTTPNode struct {
 	*baseNode
-	url *url.URL // stores url pointing actual remote file. (e.g. with Taskfile.yml)
+	url      *url.URL // stores url pointing actual remote file. (e.g. with Taskfile.yml)
+	pinned   *url.URL // the exact URL to read, as recorded in a lockfile
+	resolved *url.URL // the exact URL the file was last read from
 }
 
 func NewHTTPNode(
@@ -49,14 +51,36 @@ func (node *HTTPNode) Read() ([]byte, error) {
 }
 
 func (node *HTTPNode) ReadContext(ctx context.Context) ([]byte, error) {
-	url, err := RemoteExists(ctx, *node.url)
+	u := *node.url
+	if node.pinned != nil {
+		u = *node.pinned
+	}
+	url, err := remoteExists(ctx, u, node.auth)
 	if err != nil {
 		return nil, err
 	}
+	node.resolved = url
+	return node.fetch(ctx, url)
+}
+
+func (node *HTTPNode) ReadSignatureContext(ctx context.Context) ([]byte, error) {
+	u := *node.url
+	if node.resolved != nil {
+		u = *node.resolved
+	}
+	u.Path += signatureExtension
+	return node.fetch(ctx, &u)
+}
+
+// fetch downloads the file at the given URL.
+func (node *HTTPNode) fetch(ctx context.Context, url *url.URL) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return nil, errors.TaskfileFetchFailedError{URI: node.Location()}
 	}
+	if err := authorize(ctx, node.auth, req); err != nil {
+		return nil, err
+	}
 
 	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
 	if err != nil {
@@ -82,6 +106,24 @@ func (node *HTTPNode) ReadContext(ctx context.Context) ([]byte, error) {
 	return b, nil
 }
 
+func (node *HTTPNode) Pin(remote *LockedRemote) {
+	u, err := url.Parse(remote.URL)
+	if err != nil {
+		return
+	}
+	// Credentials are redacted in lockfiles, so reuse the ones of the include
+	u.User = node.url.User
+	node.pinned = u
+}
+
+func (node *HTTPNode) Resolved() *LockedRemote {
+	u := node.url
+	if node.resolved != nil {
+		u = node.resolved
+	}
+	return &LockedRemote{URL: u.Redacted()}
+}
+
 func (node *HTTPNode) ResolveEntrypoint(entrypoint string) (string, error) {
 	ref, err := url.Parse(entrypoint)
 	if err != nil {
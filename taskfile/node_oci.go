@@ -0,0 +1,450 @@
+package taskfile
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vikbert/taskr/v3/errors"
+	"github.com/vikbert/taskr/v3/internal/execext"
+	"github.com/vikbert/taskr/v3/internal/filepathext"
+)
+
+const (
+	ociScheme = "oci://"
+	// ociTitleAnnotation is the annotation that holds the file name of a
+	// layer, as set by tools such as ORAS.
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// ociMaxBlobSize is the maximum size of a Taskfile pulled from a registry.
+	ociMaxBlobSize = 10 << 20
+)
+
+// ociManifestMediaTypes are the manifest formats that can be pulled.
+var ociManifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// An OCINode is a node that reads a Taskfile stored as an artifact in an OCI
+// registry. The artifact can contain several Taskfiles, one per layer, named
+// with the "org.opencontainers.image.title" annotation.
+type OCINode struct {
+	*baseNode
+	rawURL     string
+	insecure   bool
+	registry   string
+	repository string
+	tag        string
+	digest     string
+	path       string
+	// manifest is the manifest of the artifact, once pulled
+	manifest *ociManifest
+	// resolvedDigest is the digest of the manifest that was last pulled
+	resolvedDigest string
+	// scheme is the protocol used to reach the registry, once known
+	scheme string
+	// token is the bearer token issued by the registry, if it requires one
+	token string
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func NewOCINode(
+	entrypoint string,
+	dir string,
+	insecure bool,
+	opts ...NodeOption,
+) (*OCINode, error) {
+	base := NewBaseNode(dir, opts...)
+	ref, ok := strings.CutPrefix(entrypoint, ociScheme)
+	if !ok {
+		return nil, fmt.Errorf("task: Invalid OCI reference %q", entrypoint)
+	}
+	ref, filePath, _ := strings.Cut(ref, "//")
+	registry, repository, ok := strings.Cut(ref, "/")
+	if !ok || registry == "" || repository == "" {
+		return nil, fmt.Errorf("task: Invalid OCI reference %q", entrypoint)
+	}
+
+	node := &OCINode{
+		baseNode: base,
+		rawURL:   entrypoint,
+		insecure: insecure,
+		registry: registry,
+		path:     filePath,
+	}
+	if repo, digest, ok := strings.Cut(repository, "@"); ok {
+		repository = repo
+		node.digest = digest
+		if !strings.HasPrefix(digest, "sha256:") {
+			return nil, fmt.Errorf("task: Unsupported digest %q in OCI reference %q", digest, entrypoint)
+		}
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		node.tag = repository[i+1:]
+		repository = repository[:i]
+	}
+	if node.tag == "" && node.digest == "" {
+		node.tag = "latest"
+	}
+	node.repository = repository
+	return node, nil
+}
+
+func (node *OCINode) Location() string {
+	return node.rawURL
+}
+
+func (node *OCINode) Read() ([]byte, error) {
+	return node.ReadContext(context.Background())
+}
+
+func (node *OCINode) ReadContext(ctx context.Context) ([]byte, error) {
+	manifest, err := node.pullManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := node.findLayer(manifest, node.path)
+	if err != nil {
+		return nil, err
+	}
+	return node.pullBlob(ctx, layer)
+}
+
+func (node *OCINode) ReadSignatureContext(ctx context.Context) ([]byte, error) {
+	manifest, err := node.pullManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := node.findLayer(manifest, node.path)
+	if err != nil {
+		return nil, err
+	}
+	title := layer.Annotations[ociTitleAnnotation]
+	if title == "" {
+		return nil, fmt.Errorf("task: Layer %s of %q has no title to find its signature", layer.Digest, node.Location())
+	}
+	signature, err := node.findLayer(manifest, title+signatureExtension)
+	if err != nil {
+		return nil, err
+	}
+	return node.pullBlob(ctx, signature)
+}
+
+func (node *OCINode) Pin(remote *LockedRemote) {
+	pinned, err := NewOCINode(remote.URL, "", node.insecure)
+	if err != nil || pinned.digest == "" {
+		return
+	}
+	node.digest = pinned.digest
+}
+
+func (node *OCINode) Resolved() *LockedRemote {
+	digest := cmp.Or(node.resolvedDigest, node.digest)
+	ref := fmt.Sprintf("%s%s/%s@%s", ociScheme, node.registry, node.repository, digest)
+	if node.path != "" {
+		ref += "//" + node.path
+	}
+	return &LockedRemote{URL: ref}
+}
+
+func (node *OCINode) ResolveEntrypoint(entrypoint string) (string, error) {
+	// If the file is remote, we don't need to resolve the path
+	if isRemoteEntrypoint(entrypoint) {
+		return entrypoint, nil
+	}
+
+	// Relative includes are read from the same artifact
+	dir := path.Dir(node.path)
+	return fmt.Sprintf("%s%s/%s%s//%s", ociScheme, node.registry, node.repository, node.reference(), path.Join(dir, filepath.ToSlash(entrypoint))), nil
+}
+
+func (node *OCINode) ResolveDir(dir string) (string, error) {
+	path, err := execext.ExpandLiteral(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if filepathext.IsAbs(path) {
+		return path, nil
+	}
+
+	// NOTE: Uses the directory of the entrypoint (Taskfile), not the current working directory
+	// This means that files are included relative to one another
+	entrypointDir := filepath.Dir(node.Dir())
+	return filepathext.SmartJoin(entrypointDir, path), nil
+}
+
+func (node *OCINode) CacheKey() string {
+	checksum := strings.TrimRight(checksum([]byte(node.Location())), "=")
+	prefix := path.Base(node.repository)
+	if node.path != "" {
+		prefix = fmt.Sprintf("%s.%s", prefix, path.Base(node.path))
+	}
+	return fmt.Sprintf("oci.%s.%s.%s", node.registry, prefix, checksum)
+}
+
+// reference returns the tag and digest of the artifact, as written in OCI
+// references.
+func (node *OCINode) reference() string {
+	var ref string
+	if node.tag != "" {
+		ref += ":" + node.tag
+	}
+	if node.digest != "" {
+		ref += "@" + node.digest
+	}
+	return ref
+}
+
+// pullManifest pulls the manifest of the artifact and checks it against the
+// pinned digest, if any.
+func (node *OCINode) pullManifest(ctx context.Context) (*ociManifest, error) {
+	if node.manifest != nil {
+		return node.manifest, nil
+	}
+
+	ref := cmp.Or(node.digest, node.tag)
+	resp, err := node.get(ctx, fmt.Sprintf("/v2/%s/manifests/%s", node.repository, ref), strings.Join(ociManifestMediaTypes, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, ociMaxBlobSize))
+	if err != nil {
+		return nil, err
+	}
+
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+	if node.digest != "" && digest != node.digest {
+		return nil, &errors.TaskfileDoesNotMatchChecksum{
+			URI:              node.Location(),
+			ExpectedChecksum: node.digest,
+			ActualChecksum:   digest,
+		}
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("task: Invalid manifest for %q: %w", node.Location(), err)
+	}
+	if manifest.MediaType != "" && !slices.Contains(ociManifestMediaTypes, manifest.MediaType) {
+		return nil, fmt.Errorf("task: Unsupported manifest type %q for %q", manifest.MediaType, node.Location())
+	}
+	node.manifest = &manifest
+	node.resolvedDigest = digest
+	return &manifest, nil
+}
+
+// findLayer returns the layer of the manifest with the given title. If no
+// title is given, the layer named after one of the default Taskfiles is
+// returned, or the only layer of the artifact.
+func (node *OCINode) findLayer(manifest *ociManifest, title string) (*ociDescriptor, error) {
+	titles := []string{title}
+	if title == "" {
+		titles = DefaultTaskfiles
+	}
+	for _, title := range titles {
+		for i, layer := range manifest.Layers {
+			if layer.Annotations[ociTitleAnnotation] == title {
+				return &manifest.Layers[i], nil
+			}
+		}
+	}
+	if title == "" && len(manifest.Layers) == 1 {
+		return &manifest.Layers[0], nil
+	}
+	if title == "" {
+		return nil, errors.TaskfileNotFoundError{URI: node.Location(), Walk: false}
+	}
+	return nil, errors.TaskfileNotFoundError{URI: fmt.Sprintf("%s (%s)", node.Location(), title), Walk: false}
+}
+
+// pullBlob pulls the content of the given layer and checks it against its
+// digest.
+func (node *OCINode) pullBlob(ctx context.Context, layer *ociDescriptor) ([]byte, error) {
+	if layer.Size > ociMaxBlobSize {
+		return nil, fmt.Errorf("task: Layer %s of %q is too large", layer.Digest, node.Location())
+	}
+	resp, err := node.get(ctx, fmt.Sprintf("/v2/%s/blobs/%s", node.repository, layer.Digest), "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, ociMaxBlobSize))
+	if err != nil {
+		return nil, err
+	}
+	if digest := fmt.Sprintf("sha256:%x", sha256.Sum256(b)); digest != layer.Digest {
+		return nil, &errors.TaskfileDoesNotMatchChecksum{
+			URI:              node.Location(),
+			ExpectedChecksum: layer.Digest,
+			ActualChecksum:   digest,
+		}
+	}
+	return b, nil
+}
+
+// get sends a GET request to the registry. Registries are reached over HTTPS,
+// or over HTTP if insecure connections are allowed and HTTPS is not available.
+// If the registry requires a bearer token, one is requested from its token
+// service and the request is sent again.
+func (node *OCINode) get(ctx context.Context, p string, accept string) (*http.Response, error) {
+	schemes := []string{"https"}
+	if node.scheme != "" {
+		schemes = []string{node.scheme}
+	} else if node.insecure {
+		schemes = append(schemes, "http")
+	}
+
+	var resp *http.Response
+	var err error
+	for _, scheme := range schemes {
+		u := &url.URL{Scheme: scheme, Host: node.registry, Path: p}
+		if resp, err = node.do(ctx, u, accept); err == nil {
+			node.scheme = scheme
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, errors.TaskfileFetchFailedError{URI: node.Location()}
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && node.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if node.token, err = node.requestToken(ctx, challenge); err != nil {
+			return nil, err
+		}
+		u := &url.URL{Scheme: node.scheme, Host: node.registry, Path: p}
+		if resp, err = node.do(ctx, u, accept); err != nil {
+			return nil, errors.TaskfileFetchFailedError{URI: node.Location()}
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.TaskfileFetchFailedError{
+			URI:            node.Location(),
+			HTTPStatusCode: resp.StatusCode,
+		}
+	}
+	return resp, nil
+}
+
+func (node *OCINode) do(ctx context.Context, u *url.URL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if node.token != "" {
+		req.Header.Set("Authorization", "Bearer "+node.token)
+	} else if err := authorize(ctx, node.auth, req); err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// requestToken requests a bearer token from the token service described by the
+// given WWW-Authenticate challenge. The token service must be reached over
+// HTTPS unless insecure connections are allowed, and the credentials configured
+// for the registry are only sent to it when it is on the same host, since the
+// challenge could point anywhere.
+func (node *OCINode) requestToken(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", errors.TaskfileFetchFailedError{URI: node.Location(), HTTPStatusCode: http.StatusUnauthorized}
+	}
+	values := parseChallenge(params)
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return "", fmt.Errorf("task: Invalid authentication challenge from registry %q", node.registry)
+	}
+	if realm.Scheme != "https" && (realm.Scheme != "http" || !node.insecure) {
+		return "", fmt.Errorf("task: Token service %q of registry %q must be reached over HTTPS", realm.Redacted(), node.registry)
+	}
+	query := realm.Query()
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", cmp.Or(values["scope"], fmt.Sprintf("repository:%s:pull", node.repository)))
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	registry := &url.URL{Host: node.registry}
+	if a := authFor(node.auth, registry); a != nil && realm.Hostname() == registry.Hostname() {
+		creds, err := a.resolve(ctx, node.registry)
+		if err != nil {
+			return "", err
+		}
+		if creds != nil && creds.token == "" {
+			req.SetBasicAuth(creds.username, creds.password)
+		} else if creds != nil {
+			req.Header.Set("Authorization", "Bearer "+creds.token)
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.TaskfileFetchFailedError{URI: node.Location()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.TaskfileFetchFailedError{URI: node.Location(), HTTPStatusCode: resp.StatusCode}
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("task: Invalid token from registry %q: %w", node.registry, err)
+	}
+	return cmp.Or(token.Token, token.AccessToken), nil
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of a
+// WWW-Authenticate challenge.
+func parseChallenge(params string) map[string]string {
+	values := map[string]string{}
+	for params != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(params, ", "), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = value
+		params = rest
+	}
+	return values
+}
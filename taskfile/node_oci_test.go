@@ -0,0 +1,303 @@
+package taskfile
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+func TestOCINode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entrypoint string
+		registry   string
+		repository string
+		tag        string
+		digest     string
+		path       string
+		resolved   string
+	}{
+		{
+			entrypoint: "oci://ghcr.io/foo/tasks",
+			registry:   "ghcr.io",
+			repository: "foo/tasks",
+			tag:        "latest",
+			resolved:   "oci://ghcr.io/foo/tasks:latest//common.yml",
+		},
+		{
+			entrypoint: "oci://localhost:5000/foo/tasks:v1//dir/Taskfile.yml",
+			registry:   "localhost:5000",
+			repository: "foo/tasks",
+			tag:        "v1",
+			path:       "dir/Taskfile.yml",
+			resolved:   "oci://localhost:5000/foo/tasks:v1//dir/common.yml",
+		},
+		{
+			entrypoint: "oci://ghcr.io/foo/tasks@sha256:abc",
+			registry:   "ghcr.io",
+			repository: "foo/tasks",
+			digest:     "sha256:abc",
+			resolved:   "oci://ghcr.io/foo/tasks@sha256:abc//common.yml",
+		},
+		{
+			entrypoint: "oci://ghcr.io/foo/tasks:v1@sha256:abc//Taskfile.yml",
+			registry:   "ghcr.io",
+			repository: "foo/tasks",
+			tag:        "v1",
+			digest:     "sha256:abc",
+			path:       "Taskfile.yml",
+			resolved:   "oci://ghcr.io/foo/tasks:v1@sha256:abc//common.yml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entrypoint, func(t *testing.T) {
+			t.Parallel()
+
+			node, err := NewOCINode(tt.entrypoint, "", false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.registry, node.registry)
+			assert.Equal(t, tt.repository, node.repository)
+			assert.Equal(t, tt.tag, node.tag)
+			assert.Equal(t, tt.digest, node.digest)
+			assert.Equal(t, tt.path, node.path)
+			entrypoint, err := node.ResolveEntrypoint("common.yml")
+			require.NoError(t, err)
+			assert.Equal(t, tt.resolved, entrypoint)
+		})
+	}
+
+	for _, entrypoint := range []string{"oci://ghcr.io", "oci:///foo", "oci://ghcr.io/foo@md5:abc"} {
+		_, err := NewOCINode(entrypoint, "", false)
+		assert.Error(t, err, entrypoint)
+	}
+}
+
+// registry is an in-process OCI registry serving a single artifact.
+type registry struct {
+	manifest []byte
+	blobs    map[string][]byte
+	token    string
+}
+
+func newRegistry(t *testing.T, files map[string][]byte) *registry {
+	t.Helper()
+
+	reg := &registry{blobs: map[string][]byte{}}
+	var layers []ociDescriptor
+	for title, content := range files {
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+		reg.blobs[digest] = content
+		layers = append(layers, ociDescriptor{
+			MediaType:   "application/vnd.taskr.taskfile.v1+yaml",
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitleAnnotation: title},
+		})
+	}
+	manifest, err := json.Marshal(ociManifest{
+		MediaType: ociManifestMediaTypes[0],
+		Layers:    layers,
+	})
+	require.NoError(t, err)
+	reg.manifest = manifest
+	return reg
+}
+
+func (reg *registry) digest() string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(reg.manifest))
+}
+
+func (reg *registry) serve(t *testing.T) string {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": reg.token})
+			return
+		}
+		if reg.token != "" && r.Header.Get("Authorization") != "Bearer "+reg.token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/foo/tasks/manifests/"):
+			// Every reference serves the same manifest, so that digests are
+			// checked by the client
+			w.Header().Set("Content-Type", ociManifestMediaTypes[0])
+			_, _ = w.Write(reg.manifest)
+		case strings.HasPrefix(r.URL.Path, "/v2/foo/tasks/blobs/"):
+			blob, ok := reg.blobs[strings.TrimPrefix(r.URL.Path, "/v2/foo/tasks/blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.Listener.Addr().String()
+}
+
+func TestOCINodeRead(t *testing.T) {
+	t.Parallel()
+
+	taskfile := []byte("version: '3'\nincludes:\n  common: ./lib/common.yml\n")
+	common := []byte("version: '3'\n")
+	reg := newRegistry(t, map[string][]byte{
+		"Taskfile.yml":   taskfile,
+		"lib/common.yml": common,
+	})
+	host := reg.serve(t)
+
+	t.Run("tag", func(t *testing.T) {
+		t.Parallel()
+
+		node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1", host), "", true)
+		require.NoError(t, err)
+		b, err := node.ReadContext(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, taskfile, b)
+		assert.Equal(t, fmt.Sprintf("oci://%s/foo/tasks@%s", host, reg.digest()), node.Resolved().URL)
+
+		entrypoint, err := node.ResolveEntrypoint("./lib/common.yml")
+		require.NoError(t, err)
+		included, err := NewOCINode(entrypoint, "", true)
+		require.NoError(t, err)
+		b, err = included.ReadContext(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, common, b)
+	})
+
+	t.Run("digest", func(t *testing.T) {
+		t.Parallel()
+
+		node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks@%s//lib/common.yml", host, reg.digest()), "", true)
+		require.NoError(t, err)
+		b, err := node.ReadContext(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, common, b)
+	})
+
+	t.Run("pinned digest mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1", host), "", true)
+		require.NoError(t, err)
+		node.Pin(&LockedRemote{URL: fmt.Sprintf("oci://%s/foo/tasks@sha256:%x", host, sha256.Sum256(nil))})
+		_, err = node.ReadContext(t.Context())
+		var checksumErr *errors.TaskfileDoesNotMatchChecksum
+		require.ErrorAs(t, err, &checksumErr)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1//missing.yml", host), "", true)
+		require.NoError(t, err)
+		_, err = node.ReadContext(t.Context())
+		var notFoundErr errors.TaskfileNotFoundError
+		require.ErrorAs(t, err, &notFoundErr)
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		t.Parallel()
+
+		node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1//lib/common.yml", host), "", true)
+		require.NoError(t, err)
+		tempDir := t.TempDir()
+		r := NewReader(
+			WithInsecure(true),
+			WithTempDir(tempDir),
+			WithTrustedHosts([]string{host}),
+			WithCacheExpiryDuration(time.Hour),
+		)
+		_, err = r.Read(t.Context(), node)
+		require.NoError(t, err)
+
+		// The Taskfile is stored in the cache
+		b, err := NewCacheNode(node, tempDir).Read()
+		require.NoError(t, err)
+		assert.Equal(t, common, b)
+	})
+}
+
+func TestOCINodeToken(t *testing.T) {
+	t.Setenv("OCI_TEST_PASSWORD", "pass")
+
+	reg := newRegistry(t, map[string][]byte{"Taskfile.yml": []byte("version: '3'\n")})
+	reg.token = "secret"
+	host := reg.serve(t)
+
+	node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1", host), "", true)
+	require.NoError(t, err)
+	_, err = node.ReadContext(t.Context())
+	var fetchErr errors.TaskfileFetchFailedError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, http.StatusUnauthorized, fetchErr.HTTPStatusCode)
+
+	node, err = NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1", host), "", true, WithHostAuth(map[string]*Auth{
+		host: {Username: "user", PasswordEnv: "OCI_TEST_PASSWORD"},
+	}))
+	require.NoError(t, err)
+	_, err = node.ReadContext(t.Context())
+	require.NoError(t, err)
+}
+
+func TestOCINodeTokenService(t *testing.T) {
+	t.Setenv("OCI_TEST_PASSWORD", "pass")
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+	}))
+	t.Cleanup(srv.Close)
+
+	newNode := func(registry string, insecure bool) *OCINode {
+		node, err := NewOCINode(fmt.Sprintf("oci://%s/foo/tasks:v1", registry), "", insecure, WithHostAuth(map[string]*Auth{
+			registry: {Username: "user", PasswordEnv: "OCI_TEST_PASSWORD"},
+		}))
+		require.NoError(t, err)
+		return node
+	}
+	challenge := fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, srv.URL)
+
+	// The token service is reached over HTTPS unless insecure connections
+	// are allowed
+	_, err := newNode(srv.Listener.Addr().String(), false).requestToken(t.Context(), challenge)
+	require.EqualError(t, err, fmt.Sprintf(`task: Token service %q of registry %q must be reached over HTTPS`, srv.URL+"/token", srv.Listener.Addr().String()))
+	assert.Empty(t, authorization)
+
+	// The credentials are sent to a token service on the registry host
+	token, err := newNode(srv.Listener.Addr().String(), true).requestToken(t.Context(), challenge)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+	assert.NotEmpty(t, authorization)
+
+	// But not to one on another host
+	authorization = ""
+	token, err = newNode("registry.example.com", true).requestToken(t.Context(), challenge)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+	assert.Empty(t, authorization)
+}
@@ -0,0 +1,208 @@
+package taskfile
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vikbert/taskr/v3/internal/filepathext"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// A ParsedCache stores the merged Taskfile of a graph of local Taskfiles, so
+// that they don't have to be read and decoded again on the next run. The
+// cached Taskfile is only used if the version of Task and the checksums of
+// every Taskfile in the graph are unchanged.
+type ParsedCache struct {
+	dir     string
+	path    string
+	version string
+}
+
+// ParsedCacheFormat is the version of the format of the [ParsedCache]. It must
+// be incremented whenever the encoding of [ast.Taskfile] changes, so that
+// builds with a different AST don't decode stale entries that share their
+// version of Task.
+const ParsedCacheFormat = 2
+
+// parsedCacheEntry is the content of a [ParsedCache] file.
+type parsedCacheEntry struct {
+	Version string
+	// Checksums are the checksums of every Taskfile in the graph, keyed by
+	// their location.
+	Checksums map[string]string
+	// Missing are the files that would have been read instead of an included
+	// directory's Taskfile if they existed.
+	Missing  []string
+	Taskfile *ast.Taskfile
+}
+
+// NewParsedCache returns a [ParsedCache] for the root Taskfile at the given
+// location, stored in the given directory. Nothing is cached if the directory
+// is empty.
+func NewParsedCache(dir, location, version string) *ParsedCache {
+	key := strings.TrimRight(checksum([]byte(location)), "=")
+	return &ParsedCache{
+		dir:     dir,
+		path:    filepath.Join(dir, fmt.Sprintf("%s-v%d.gob", key, ParsedCacheFormat)),
+		version: fmt.Sprintf("%d/%s", ParsedCacheFormat, version),
+	}
+}
+
+// Read returns the cached Taskfile and the locations of the Taskfiles it was
+// merged from. It reports false if there is no cached Taskfile or if any of
+// them changed.
+func (c *ParsedCache) Read() (*ast.Taskfile, []string, bool) {
+	if c.dir == "" {
+		return nil, nil, false
+	}
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, nil, false
+	}
+	var entry parsedCacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&entry); err != nil {
+		return nil, nil, false
+	}
+	if entry.Version != c.version || entry.Taskfile == nil {
+		return nil, nil, false
+	}
+	locations := make([]string, 0, len(entry.Checksums))
+	for location, sum := range entry.Checksums {
+		b, err := os.ReadFile(location)
+		if err != nil || checksum(b) != sum {
+			return nil, nil, false
+		}
+		locations = append(locations, location)
+	}
+	for _, path := range entry.Missing {
+		if _, err := os.Stat(path); err == nil {
+			return nil, nil, false
+		}
+	}
+	return entry.Taskfile, locations, true
+}
+
+// Write stores the given Taskfile, merged from the given graph. Nothing is
+// stored if the graph can't be cached, because it contains remote Taskfiles or
+// includes that depend on the environment.
+func (c *ParsedCache) Write(graph *ast.TaskfileGraph, tf *ast.Taskfile) error {
+	if c.dir == "" {
+		return nil
+	}
+	entry, ok, err := newParsedCacheEntry(graph)
+	if err != nil || !ok {
+		return err
+	}
+	entry.Version = c.version
+	entry.Taskfile = tf
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that concurrent runs never read a
+	// partially written cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func newParsedCacheEntry(graph *ast.TaskfileGraph) (*parsedCacheEntry, bool, error) {
+	adjacencyMap, err := graph.AdjacencyMap()
+	if err != nil {
+		return nil, false, err
+	}
+	entry := &parsedCacheEntry{Checksums: map[string]string{}}
+	for location, edges := range adjacencyMap {
+		vertex, err := graph.Vertex(location)
+		if err != nil {
+			return nil, false, err
+		}
+		// Only local Taskfiles are cached
+		if vertex.Checksum == "" {
+			return nil, false, nil
+		}
+		entry.Checksums[location] = vertex.Checksum
+
+		// Includes that use variables or globs could resolve to other
+		// Taskfiles or be skipped on the next run
+		var declared int
+		for include := range vertex.Taskfile.Includes.Values() {
+			if isDynamic(include.Taskfile) || isDynamic(include.Dir) || include.If != "" || ast.IsGlobNamespace(include.Namespace) {
+				return nil, false, nil
+			}
+			// Includes for other platforms are never read
+			if !ast.MatchesCurrentPlatform(include.Platforms) {
+				continue
+			}
+			declared++
+		}
+
+		var included int
+		for target, edge := range edges {
+			includes, _ := edge.Properties.Data.([]*ast.Include)
+			for _, include := range includes {
+				included++
+				missing, ok := missingTaskfiles(location, include.Taskfile, target)
+				if !ok {
+					return nil, false, nil
+				}
+				entry.Missing = append(entry.Missing, missing...)
+			}
+		}
+		// Optional includes that were not found could be created later
+		if included != declared {
+			return nil, false, nil
+		}
+	}
+	return entry, true, nil
+}
+
+// missingTaskfiles returns the files that would have been included instead of
+// the target if they existed. This is only the case for includes that refer to
+// a directory, where the first of the default Taskfiles found is included.
+func missingTaskfiles(parent, entrypoint, target string) ([]string, bool) {
+	path := entrypoint
+	if !filepathext.IsAbs(path) {
+		path = filepathext.SmartJoin(filepath.Dir(parent), path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false
+	}
+	if path == target {
+		return nil, true
+	}
+	var missing []string
+	for _, name := range DefaultTaskfiles {
+		candidate := filepath.Join(path, name)
+		if candidate == target {
+			return missing, true
+		}
+		missing = append(missing, candidate)
+	}
+	return nil, false
+}
+
+// isDynamic reports whether the given path depends on variables or on the
+// environment.
+func isDynamic(s string) bool {
+	return strings.Contains(s, "{{") || strings.Contains(s, "$")
+}
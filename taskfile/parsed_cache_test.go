@@ -0,0 +1,86 @@
+package taskfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsedCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(t *testing.T, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write(t, "Taskfile.yml", "version: '3'\nincludes:\n  lib: ./lib.yml\n  docs: ./docs\ntasks:\n  default: echo root\n")
+	write(t, "lib.yml", "version: '3'\ntasks:\n  build: echo lib\n")
+	write(t, "docs/taskfile.yml", "version: '3'\ntasks:\n  serve: echo docs\n")
+
+	root := filepath.Join(dir, "Taskfile.yml")
+	cacheDir := filepath.Join(dir, ".task", "taskfile")
+	store := func(t *testing.T, version string) {
+		t.Helper()
+		node, err := NewFileNode(root, dir)
+		require.NoError(t, err)
+		graph, err := NewReader().Read(t.Context(), node)
+		require.NoError(t, err)
+		tf, err := graph.Merge()
+		require.NoError(t, err)
+		require.NoError(t, NewParsedCache(cacheDir, root, version).Write(graph, tf))
+	}
+
+	store(t, "v1")
+	tf, locations, ok := NewParsedCache(cacheDir, root, "v1").Read()
+	require.True(t, ok)
+	assert.Len(t, locations, 3)
+	_, ok = tf.Tasks.Get("lib:build")
+	assert.True(t, ok)
+
+	// An empty directory disables the cache
+	_, _, ok = NewParsedCache("", root, "v1").Read()
+	assert.False(t, ok)
+
+	// A new version of Task invalidates the cache
+	_, _, ok = NewParsedCache(cacheDir, root, "v2").Read()
+	assert.False(t, ok)
+
+	// Changing an included Taskfile invalidates the cache
+	write(t, "lib.yml", "version: '3'\ntasks:\n  test: echo lib\n")
+	_, _, ok = NewParsedCache(cacheDir, root, "v1").Read()
+	assert.False(t, ok)
+
+	// Creating a Taskfile that takes precedence in an included directory
+	// invalidates the cache
+	store(t, "v1")
+	_, _, ok = NewParsedCache(cacheDir, root, "v1").Read()
+	require.True(t, ok)
+	write(t, "docs/Taskfile.yml", "version: '3'\n")
+	_, _, ok = NewParsedCache(cacheDir, root, "v1").Read()
+	assert.False(t, ok)
+
+	// Includes that depend on variables are never cached
+	write(t, "Taskfile.yml", "version: '3'\nincludes:\n  lib: ./{{.LIB}}.yml\nvars:\n  LIB: lib\n")
+	require.NoError(t, os.RemoveAll(cacheDir))
+	store(t, "v1")
+	_, _, ok = NewParsedCache(cacheDir, root, "v1").Read()
+	assert.False(t, ok)
+
+	// Includes with conditions are never cached, but includes for other
+	// platforms are
+	write(t, "Taskfile.yml", "version: '3'\nincludes:\n  lib:\n    taskfile: ./lib.yml\n    if: 'true'\n")
+	require.NoError(t, os.RemoveAll(cacheDir))
+	store(t, "v1")
+	_, _, ok = NewParsedCache(cacheDir, root, "v1").Read()
+	assert.False(t, ok)
+	write(t, "Taskfile.yml", "version: '3'\nincludes:\n  lib:\n    taskfile: ./lib.yml\n    platforms: [plan9]\n")
+	store(t, "v1")
+	_, _, ok = NewParsedCache(cacheDir, root, "v1").Read()
+	assert.True(t, ok)
+}
@@ -1,10 +1,14 @@
 package taskfile
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,6 +32,10 @@ Continue?`
 Continue?`
 )
 
+// DefaultReadConcurrency is the default maximum number of Taskfiles that a
+// [Reader] reads at the same time.
+const DefaultReadConcurrency = 16
+
 type (
 	// DebugFunc is a function that can be called to log debug messages.
 	DebugFunc func(string)
@@ -45,11 +53,29 @@ type (
 		download            bool
 		offline             bool
 		trustedHosts        []string
+		trustedKeys         []string
+		publicKeys          []*publicKey
+		auth                map[string]*Auth
 		tempDir             string
 		cacheExpiryDuration time.Duration
 		debugFunc           DebugFunc
 		promptFunc          PromptFunc
 		promptMutex         sync.Mutex
+		lockfile            *Lockfile
+		updateLock          bool
+		downloadNamespaces  []string
+		downloads           sync.Map
+		matchedNamespaces   sync.Map
+		concurrency         int
+		readSemaphore       chan struct{}
+		timings             []*readTiming
+		timingsMutex        sync.Mutex
+	}
+	// A readTiming records how long a Taskfile took to be read and decoded.
+	readTiming struct {
+		location string
+		read     time.Duration
+		decode   time.Duration
 	}
 )
 
@@ -62,11 +88,22 @@ func NewReader(opts ...ReaderOption) *Reader {
 		download:            false,
 		offline:             false,
 		trustedHosts:        nil,
+		trustedKeys:         nil,
+		publicKeys:          nil,
+		auth:                nil,
 		tempDir:             os.TempDir(),
 		cacheExpiryDuration: 0,
 		debugFunc:           nil,
 		promptFunc:          nil,
 		promptMutex:         sync.Mutex{},
+		lockfile:            nil,
+		updateLock:          false,
+		downloadNamespaces:  nil,
+		downloads:           sync.Map{},
+		concurrency:         DefaultReadConcurrency,
+		readSemaphore:       nil,
+		timings:             nil,
+		timingsMutex:        sync.Mutex{},
 	}
 	r.Options(opts...)
 	return r
@@ -108,6 +145,22 @@ func (o *downloadOption) ApplyToReader(r *Reader) {
 	r.download = o.download
 }
 
+// WithDownloadNamespaces forces the [Reader] to download a fresh copy of the
+// remote taskfiles included under the given namespaces, including the ones
+// they include themselves. Other remote taskfiles are read from the cache as
+// usual.
+func WithDownloadNamespaces(namespaces []string) ReaderOption {
+	return &downloadNamespacesOption{namespaces: namespaces}
+}
+
+type downloadNamespacesOption struct {
+	namespaces []string
+}
+
+func (o *downloadNamespacesOption) ApplyToReader(r *Reader) {
+	r.downloadNamespaces = o.namespaces
+}
+
 // WithOffline stops the [Reader] from being able to make network connections.
 // It will still be able to read local files and cached copies of remote files.
 func WithOffline(offline bool) ReaderOption {
@@ -136,6 +189,35 @@ func (o *trustedHostsOption) ApplyToReader(r *Reader) {
 	r.trustedHosts = o.trustedHosts
 }
 
+// WithTrustedKeys configures the [Reader] with a list of minisign public keys.
+// If any are given, every remote Taskfile must have a detached signature made
+// by one of them, or it will be refused.
+func WithTrustedKeys(trustedKeys []string) ReaderOption {
+	return &trustedKeysOption{trustedKeys: trustedKeys}
+}
+
+type trustedKeysOption struct {
+	trustedKeys []string
+}
+
+func (o *trustedKeysOption) ApplyToReader(r *Reader) {
+	r.trustedKeys = o.trustedKeys
+}
+
+// WithAuth configures the [Reader] with the auth used to authenticate the
+// requests made to download remote Taskfiles, keyed by host.
+func WithAuth(auth map[string]*Auth) ReaderOption {
+	return &authOption{auth: auth}
+}
+
+type authOption struct {
+	auth map[string]*Auth
+}
+
+func (o *authOption) ApplyToReader(r *Reader) {
+	r.auth = o.auth
+}
+
 // WithTempDir sets the temporary directory that will be used by the [Reader].
 // By default, the reader uses [os.TempDir].
 func WithTempDir(tempDir string) ReaderOption {
@@ -199,6 +281,49 @@ func (o *promptFuncOption) ApplyToReader(r *Reader) {
 	r.promptFunc = o.promptFunc
 }
 
+// WithLockfile sets the [Lockfile] used by the [Reader]. Every remote Taskfile
+// that is read must match the URL, commit and checksum recorded in it. By
+// default, no lockfile is used and remote Taskfiles are not pinned.
+func WithLockfile(lockfile *Lockfile) ReaderOption {
+	return &lockfileOption{lockfile: lockfile}
+}
+
+type lockfileOption struct {
+	lockfile *Lockfile
+}
+
+func (o *lockfileOption) ApplyToReader(r *Reader) {
+	r.lockfile = o.lockfile
+}
+
+// WithUpdateLock makes the [Reader] download every remote Taskfile and record
+// its state in the [Lockfile] given to [WithLockfile] instead of enforcing it.
+func WithUpdateLock(updateLock bool) ReaderOption {
+	return &updateLockOption{updateLock: updateLock}
+}
+
+type updateLockOption struct {
+	updateLock bool
+}
+
+func (o *updateLockOption) ApplyToReader(r *Reader) {
+	r.updateLock = o.updateLock
+}
+
+// WithConcurrency sets the maximum number of Taskfiles that the [Reader] reads
+// at the same time. Defaults to [DefaultReadConcurrency].
+func WithConcurrency(concurrency int) ReaderOption {
+	return &concurrencyOption{concurrency: concurrency}
+}
+
+type concurrencyOption struct {
+	concurrency int
+}
+
+func (o *concurrencyOption) ApplyToReader(r *Reader) {
+	r.concurrency = o.concurrency
+}
+
 // Read will read the Taskfile defined by the [Reader]'s [Node] and recurse
 // through any [ast.Includes] it finds, reading each included Taskfile and
 // building an [ast.TaskfileGraph] as it goes. If any errors occur, they will be
@@ -209,13 +334,96 @@ func (r *Reader) Read(ctx context.Context, node Node) (*ast.TaskfileGraph, error
 		_ = CleanGitCache()
 	}()
 
-	if err := r.include(ctx, node); err != nil {
+	var err error
+	if r.publicKeys, err = parsePublicKeys(r.trustedKeys); err != nil {
+		return nil, err
+	}
+
+	if r.concurrency > 0 {
+		r.readSemaphore = make(chan struct{}, r.concurrency)
+	}
+
+	start := time.Now()
+	if err := r.include(ctx, node, ""); err != nil {
 		return nil, err
 	}
+	r.debugTimings(time.Since(start))
 
 	return r.graph, nil
 }
 
+// acquireReadLimit waits until the [Reader] can read another Taskfile and
+// returns a function that releases the slot.
+func (r *Reader) acquireReadLimit() func() {
+	if r.readSemaphore == nil {
+		return func() {}
+	}
+
+	r.readSemaphore <- struct{}{}
+	return func() {
+		<-r.readSemaphore
+	}
+}
+
+// debugTimings prints how long each Taskfile took to be read and decoded, from
+// the slowest to the fastest. Nothing is printed when no Taskfiles are
+// included.
+func (r *Reader) debugTimings(total time.Duration) {
+	r.timingsMutex.Lock()
+	defer r.timingsMutex.Unlock()
+	if r.debugFunc == nil || len(r.timings) < 2 {
+		return
+	}
+	slices.SortFunc(r.timings, func(a, b *readTiming) int {
+		return cmp.Or(
+			cmp.Compare(b.read+b.decode, a.read+a.decode),
+			strings.Compare(a.location, b.location),
+		)
+	})
+	r.debugf("read %d Taskfiles in %s\n", len(r.timings), total.Round(time.Microsecond))
+	for _, timing := range r.timings {
+		r.debugf("  %s: read in %s, decoded in %s\n",
+			timing.location,
+			timing.read.Round(time.Microsecond),
+			timing.decode.Round(time.Microsecond),
+		)
+	}
+}
+
+// shouldDownload reports whether a fresh copy of the given node has to be
+// downloaded, ignoring the cache.
+func (r *Reader) shouldDownload(node Node) bool {
+	if r.download {
+		return true
+	}
+	_, ok := r.downloads.Load(node.Location())
+	return ok
+}
+
+// downloadNamespaceOf returns the namespace given to [WithDownloadNamespaces]
+// that selects the Taskfile included under the given namespace, if any.
+func (r *Reader) downloadNamespaceOf(namespace string) (string, bool) {
+	for _, ns := range r.downloadNamespaces {
+		if namespace == ns || strings.HasPrefix(namespace, ns+ast.NamespaceSeparator) {
+			return ns, true
+		}
+	}
+	return "", false
+}
+
+// UnmatchedDownloadNamespaces returns the namespaces given to
+// [WithDownloadNamespaces] that no remote Taskfile was included under during
+// the last call to [Reader.Read].
+func (r *Reader) UnmatchedDownloadNamespaces() []string {
+	var unmatched []string
+	for _, ns := range r.downloadNamespaces {
+		if _, ok := r.matchedNamespaces.Load(ns); !ok {
+			unmatched = append(unmatched, ns)
+		}
+	}
+	return unmatched
+}
+
 func (r *Reader) debugf(format string, a ...any) {
 	if r.debugFunc != nil {
 		r.debugFunc(fmt.Sprintf(format, a...))
@@ -251,7 +459,7 @@ func (r *Reader) isTrusted(uri string) bool {
 	return false
 }
 
-func (r *Reader) include(ctx context.Context, node Node) error {
+func (r *Reader) include(ctx context.Context, node Node, namespace string) error {
 	// Create a new vertex for the Taskfile
 	vertex := &ast.TaskfileVertex{
 		URI:      node.Location(),
@@ -267,9 +475,13 @@ func (r *Reader) include(ctx context.Context, node Node) error {
 		return err
 	}
 
-	// Read and parse the Taskfile from the file and add it to the vertex
+	// Read and parse the Taskfile from the file and add it to the vertex. The
+	// number of Taskfiles read at the same time is limited, but the slot is
+	// released before recursing so that includes can't deadlock.
 	var err error
-	vertex.Taskfile, err = r.readNode(ctx, node)
+	release := r.acquireReadLimit()
+	vertex.Taskfile, vertex.Checksum, err = r.readNode(ctx, node)
+	release()
 	if err != nil {
 		return err
 	}
@@ -283,7 +495,7 @@ func (r *Reader) include(ctx context.Context, node Node) error {
 		vars.Merge(vertex.Taskfile.Vars, nil)
 		// Start a goroutine to process each included Taskfile
 		g.Go(func() error {
-			cache := &templater.Cache{Vars: vars}
+			cache := &templater.Cache{Vars: vars, Dir: node.Dir()}
 			include = &ast.Include{
 				Namespace:      include.Namespace,
 				Taskfile:       templater.Replace(include.Taskfile, cache),
@@ -296,66 +508,39 @@ func (r *Reader) include(ctx context.Context, node Node) error {
 				Excludes:       include.Excludes,
 				Vars:           include.Vars,
 				Checksum:       include.Checksum,
+				Platforms:      include.Platforms,
+				If:             include.If,
 			}
 			if err := cache.Err(); err != nil {
 				return err
 			}
 
-			entrypoint, err := node.ResolveEntrypoint(include.Taskfile)
+			// Skip includes that don't apply to the current platform or whose
+			// condition is false before their Taskfile is read
+			ok, err := includeApplies(include, cache)
 			if err != nil {
-				return err
+				return errors.TaskfileInvalidError{URI: node.Location(), Err: err}
 			}
-
-			include.Dir, err = node.ResolveDir(include.Dir)
-			if err != nil {
-				return err
+			if !ok {
+				r.debugf("skipping include %q\n", include.Namespace)
+				return nil
 			}
 
-			includeNode, err := NewNode(entrypoint, include.Dir, r.insecure,
-				WithParent(node),
-				WithChecksum(include.Checksum),
-			)
-			if err != nil {
-				if include.Optional {
-					return nil
+			// Glob includes are expanded into an include per matched Taskfile
+			if ast.IsGlobNamespace(include.Namespace) {
+				includes, err := expandGlobInclude(node, include)
+				if err != nil {
+					return err
 				}
-				return err
-			}
-
-			// Recurse into the included Taskfile
-			if err := r.include(ctx, includeNode); err != nil {
-				return err
-			}
-
-			// Create an edge between the Taskfiles
-			r.graph.Lock()
-			defer r.graph.Unlock()
-			edge, err := r.graph.Edge(node.Location(), includeNode.Location())
-			if err == graph.ErrEdgeNotFound {
-				// If the edge doesn't exist, create it
-				err = r.graph.AddEdge(
-					node.Location(),
-					includeNode.Location(),
-					graph.EdgeData([]*ast.Include{include}),
-					graph.EdgeWeight(1),
-				)
-			} else {
-				// If the edge already exists
-				edgeData := append(edge.Properties.Data.([]*ast.Include), include)
-				err = r.graph.UpdateEdge(
-					node.Location(),
-					includeNode.Location(),
-					graph.EdgeData(edgeData),
-					graph.EdgeWeight(len(edgeData)),
-				)
-			}
-			if errors.Is(err, graph.ErrEdgeCreatesCycle) {
-				return errors.TaskfileCycleError{
-					Source:      node.Location(),
-					Destination: includeNode.Location(),
+				for _, include := range includes {
+					g.Go(func() error {
+						return r.includeTaskfile(ctx, node, namespace, include)
+					})
 				}
+				return nil
 			}
-			return err
+
+			return r.includeTaskfile(ctx, node, namespace, include)
 		})
 	}
 
@@ -363,14 +548,121 @@ func (r *Reader) include(ctx context.Context, node Node) error {
 	return g.Wait()
 }
 
-func (r *Reader) readNode(ctx context.Context, node Node) (*ast.Taskfile, error) {
+// includeTaskfile reads the Taskfile of the given include and adds it to the
+// graph as a child of the given node.
+func (r *Reader) includeTaskfile(ctx context.Context, node Node, namespace string, include *ast.Include) error {
+	entrypoint, err := node.ResolveEntrypoint(include.Taskfile)
+	if err != nil {
+		return err
+	}
+
+	include.Dir, err = node.ResolveDir(include.Dir)
+	if err != nil {
+		return err
+	}
+
+	includeNode, err := NewNode(entrypoint, include.Dir, r.insecure,
+		WithParent(node),
+		WithChecksum(include.Checksum),
+		WithHostAuth(r.auth),
+	)
+	if err != nil {
+		if include.Optional {
+			return nil
+		}
+		return err
+	}
+
+	includeNamespace := include.Namespace
+	if namespace != "" {
+		includeNamespace = namespace + ast.NamespaceSeparator + include.Namespace
+	}
+	if ns, ok := r.downloadNamespaceOf(includeNamespace); ok {
+		if _, remote := includeNode.(RemoteNode); remote {
+			r.downloads.Store(includeNode.Location(), struct{}{})
+			r.matchedNamespaces.Store(ns, struct{}{})
+		}
+	}
+
+	// Recurse into the included Taskfile
+	if err := r.include(ctx, includeNode, includeNamespace); err != nil {
+		return err
+	}
+
+	// Create an edge between the Taskfiles
+	r.graph.Lock()
+	defer r.graph.Unlock()
+	edge, err := r.graph.Edge(node.Location(), includeNode.Location())
+	if err == graph.ErrEdgeNotFound {
+		// If the edge doesn't exist, create it
+		err = r.graph.AddEdge(
+			node.Location(),
+			includeNode.Location(),
+			graph.EdgeData([]*ast.Include{include}),
+			graph.EdgeWeight(1),
+		)
+	} else {
+		// If the edge already exists
+		edgeData := append(edge.Properties.Data.([]*ast.Include), include)
+		err = r.graph.UpdateEdge(
+			node.Location(),
+			includeNode.Location(),
+			graph.EdgeData(edgeData),
+			graph.EdgeWeight(len(edgeData)),
+		)
+	}
+	if errors.Is(err, graph.ErrEdgeCreatesCycle) {
+		return errors.TaskfileCycleError{
+			Source:      node.Location(),
+			Destination: includeNode.Location(),
+		}
+	}
+	return err
+}
+
+// includeApplies reports whether the given include applies to the current
+// platform and whether its if condition, once templated, is true. A condition
+// that renders to an empty string is false.
+func includeApplies(include *ast.Include, cache *templater.Cache) (bool, error) {
+	if !ast.MatchesCurrentPlatform(include.Platforms) {
+		return false, nil
+	}
+	if include.If == "" {
+		return true, nil
+	}
+	condition := strings.TrimSpace(templater.Replace(include.If, cache))
+	if err := cache.Err(); err != nil {
+		return false, err
+	}
+	if condition == "" {
+		return false, nil
+	}
+	ok, err := strconv.ParseBool(condition)
+	if err != nil {
+		return false, fmt.Errorf("include %q: if condition must be true or false, got %q", include.Namespace, condition)
+	}
+	return ok, nil
+}
+
+// readNode reads and decodes the Taskfile of the given node. The checksum of
+// the Taskfile is returned along with it for local files.
+func (r *Reader) readNode(ctx context.Context, node Node) (*ast.Taskfile, string, error) {
+	timing := &readTiming{location: node.Location()}
+	start := time.Now()
 	b, err := r.readNodeContent(ctx, node)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	timing.read = time.Since(start)
 
+	start = time.Now()
 	var tf ast.Taskfile
-	if err := yaml.Unmarshal(b, &tf); err != nil {
+	err = yaml.Unmarshal(b, &tf)
+	timing.decode = time.Since(start)
+	r.timingsMutex.Lock()
+	r.timings = append(r.timings, timing)
+	r.timingsMutex.Unlock()
+	if err != nil {
 		// Decode the taskfile and add the file info the any errors
 		taskfileDecodeErr := &errors.TaskfileDecodeError{}
 		if errors.As(err, &taskfileDecodeErr) {
@@ -379,14 +671,14 @@ func (r *Reader) readNode(ctx context.Context, node Node) (*ast.Taskfile, error)
 				WithColumn(taskfileDecodeErr.Column),
 				WithPadding(2),
 			)
-			return nil, taskfileDecodeErr.WithFileInfo(node.Location(), snippet.String())
+			return nil, "", taskfileDecodeErr.WithFileInfo(node.Location(), snippet.String())
 		}
-		return nil, &errors.TaskfileInvalidError{URI: filepathext.TryAbsToRel(node.Location()), Err: err}
+		return nil, "", &errors.TaskfileInvalidError{URI: filepathext.TryAbsToRel(node.Location()), Err: err}
 	}
 
 	// Check that the Taskfile is set and has a schema version
 	if tf.Version == nil {
-		return nil, &errors.TaskfileVersionCheckError{URI: node.Location()}
+		return nil, "", &errors.TaskfileVersionCheckError{URI: node.Location()}
 	}
 
 	// Set the taskfile/task's locations
@@ -401,8 +693,13 @@ func (r *Reader) readNode(ctx context.Context, node Node) (*ast.Taskfile, error)
 			task.Location.Taskfile = tf.Location
 		}
 	}
+	tf.SetVarsTaskfile()
 
-	return &tf, nil
+	var sum string
+	if _, ok := node.(*FileNode); ok {
+		sum = checksum(b)
+	}
+	return &tf, sum, nil
 }
 
 func (r *Reader) readNodeContent(ctx context.Context, node Node) ([]byte, error) {
@@ -430,6 +727,16 @@ func (r *Reader) readNodeContent(ctx context.Context, node Node) ([]byte, error)
 }
 
 func (r *Reader) readRemoteNodeContent(ctx context.Context, node RemoteNode) ([]byte, error) {
+	// Pin the node to the state recorded in the lockfile
+	var locked *LockedRemote
+	if r.lockfile != nil && !r.updateLock {
+		var ok bool
+		if locked, ok = r.lockfile.Get(node.Location()); !ok {
+			return nil, &errors.TaskfileNotLockedError{URI: node.Location()}
+		}
+		node.Pin(locked)
+	}
+
 	cache := NewCacheNode(node, r.tempDir)
 	now := time.Now().UTC()
 	timestamp := cache.ReadTimestamp()
@@ -457,7 +764,7 @@ func (r *Reader) readRemoteNodeContent(ctx context.Context, node RemoteNode) ([]
 		// If we can't fetch a fresh copy, we should use the cache anyway
 		if r.offline {
 			r.debugf("in offline mode, using expired cache\n")
-			return cachedBytes, nil
+			return r.verifyCache(node, cache, locked, cachedBytes)
 		}
 
 	// Some other error
@@ -467,15 +774,21 @@ func (r *Reader) readRemoteNodeContent(ctx context.Context, node RemoteNode) ([]
 	// Found valid cache
 	default:
 		r.debugf("cache found\n")
-		// Not being forced to redownload, return cache
-		if !r.download {
-			return cachedBytes, nil
+		// Not being forced to redownload, return cache unless it is out of date
+		// with the lockfile
+		if !r.shouldDownload(node) && !r.updateLock && (locked == nil || checksum(cachedBytes) == locked.Checksum) {
+			return r.verifyCache(node, cache, locked, cachedBytes)
 		}
 		cacheFound = true
 	}
 
 	// Try to read the remote file
 	r.debugf("downloading remote file: %s\n", node.Location())
+	if u, err := url.Parse(node.Location()); err == nil {
+		if auth := authFor(r.auth, u); auth != nil {
+			r.debugf("authenticating to %q with %s\n", u.Host, auth.method())
+		}
+	}
 	downloadedBytes, err := node.ReadContext(ctx)
 	if err != nil {
 		// If the context timed out or was cancelled, but we found a cached version, use that
@@ -485,7 +798,7 @@ func (r *Reader) readRemoteNodeContent(ctx context.Context, node RemoteNode) ([]
 			} else {
 				r.debugf("failed to fetch remote file: %s: using expired cache\n", ctx.Err().Error())
 			}
-			return cachedBytes, nil
+			return r.verifyCache(node, cache, locked, cachedBytes)
 		}
 		return nil, err
 	}
@@ -501,9 +814,24 @@ func (r *Reader) readRemoteNodeContent(ctx context.Context, node RemoteNode) ([]
 			ActualChecksum:   checksum,
 		}
 	}
+	if _, err := r.verifyLocked(node, locked, downloadedBytes); err != nil {
+		return nil, err
+	}
+
+	// Refuse the file unless it was signed with a trusted key
+	var signature []byte
+	if len(r.publicKeys) > 0 {
+		r.debugf("downloading signature of remote file: %s\n", node.Location())
+		if signature, err = node.ReadSignatureContext(ctx); err != nil {
+			return nil, &errors.TaskfileSignatureError{URI: node.Location(), Err: err}
+		}
+		if err := r.verifySignature(node, downloadedBytes, signature); err != nil {
+			return nil, err
+		}
+	}
 
-	// If there is no manual checksum pin, run the automatic checks
-	if node.Checksum() == "" {
+	// If there is no manual, locked or signed pin, run the automatic checks
+	if node.Checksum() == "" && locked == nil && signature == nil {
 		// Prompt the user if required (unless host is trusted)
 		prompt := cache.ChecksumPrompt(checksum)
 		if prompt != "" && !r.isTrusted(node.Location()) {
@@ -527,11 +855,73 @@ func (r *Reader) readRemoteNodeContent(ctx context.Context, node RemoteNode) ([]
 		return nil, err
 	}
 
+	// Store the location the file was downloaded from
+	if err := cache.WriteSource(); err != nil {
+		return nil, err
+	}
+
+	// Store the signature so that the cached file can be verified later
+	if signature != nil {
+		if err := cache.WriteSignature(signature); err != nil {
+			return nil, err
+		}
+	}
+
 	// Cache the file
 	r.debugf("caching %q to %q\n", node.Location(), cache.Location())
 	if err = cache.Write(downloadedBytes); err != nil {
 		return nil, err
 	}
 
+	// Record the state of the file in the lockfile
+	if r.lockfile != nil && r.updateLock {
+		resolved := node.Resolved()
+		resolved.Checksum = checksum
+		r.debugf("locking %q to %q\n", node.Location(), resolved.URL)
+		r.lockfile.Set(node.Location(), resolved)
+	}
+
 	return downloadedBytes, nil
 }
+
+// verifyCache returns the given cached content if it matches the lockfile and
+// the signature stored alongside it.
+func (r *Reader) verifyCache(node RemoteNode, cache *CacheNode, locked *LockedRemote, b []byte) ([]byte, error) {
+	if _, err := r.verifyLocked(node, locked, b); err != nil {
+		return nil, err
+	}
+	if len(r.publicKeys) > 0 {
+		if err := r.verifySignature(node, b, cache.ReadSignature()); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// verifySignature checks that the given signature of the content of the node
+// was made by one of the trusted keys.
+func (r *Reader) verifySignature(node RemoteNode, b []byte, signature []byte) error {
+	if len(signature) == 0 {
+		return &errors.TaskfileSignatureError{URI: node.Location(), Err: errors.New("missing signature")}
+	}
+	if err := verifySignature(r.publicKeys, b, signature); err != nil {
+		return &errors.TaskfileSignatureError{URI: node.Location(), Err: err}
+	}
+	return nil
+}
+
+// verifyLocked returns the given content if it matches the checksum recorded
+// in the lockfile for the given node.
+func (r *Reader) verifyLocked(node RemoteNode, locked *LockedRemote, b []byte) ([]byte, error) {
+	if locked == nil {
+		return b, nil
+	}
+	if checksum := checksum(b); checksum != locked.Checksum {
+		return nil, &errors.TaskfileDoesNotMatchChecksum{
+			URI:              node.Location(),
+			ExpectedChecksum: locked.Checksum,
+			ActualChecksum:   checksum,
+		}
+	}
+	return b, nil
+}
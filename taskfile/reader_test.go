@@ -0,0 +1,80 @@
+package taskfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderMergeOrder(t *testing.T) {
+	t.Parallel()
+
+	// The root Taskfile includes many Taskfiles, some of them more than once
+	// and some of them including each other, so that they are read in a
+	// different order on every run.
+	dir := t.TempDir()
+	var includes strings.Builder
+	var want []string
+	for i := range 20 {
+		name := fmt.Sprintf("inc%02d", i)
+		content := fmt.Sprintf("version: '3'\ntasks:\n  %s: echo %s\n", name, name)
+		if i%5 == 0 && i > 0 {
+			content = fmt.Sprintf("version: '3'\nincludes:\n  prev: ./inc%02d.yml\ntasks:\n  %s: echo %s\n", i-1, name, name)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".yml"), []byte(content), 0o644))
+		fmt.Fprintf(&includes, "  %s: ./%s.yml\n", name, name)
+		want = append(want, name+":"+name)
+	}
+	root := fmt.Sprintf("version: '3'\nincludes:\n%s  again: ./inc03.yml\ntasks:\n  default: echo root\n", includes.String())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte(root), 0o644))
+
+	var first []string
+	for range 10 {
+		node, err := NewFileNode(filepath.Join(dir, "Taskfile.yml"), dir)
+		require.NoError(t, err)
+		graph, err := NewReader(WithConcurrency(4)).Read(t.Context(), node)
+		require.NoError(t, err)
+		tf, err := graph.Merge()
+		require.NoError(t, err)
+
+		keys := slices.Collect(tf.Tasks.Keys(nil))
+		if first == nil {
+			first = keys
+			continue
+		}
+		require.Equal(t, first, keys)
+	}
+	assert.Equal(t, "default", first[0])
+	assert.Equal(t, want, slices.DeleteFunc(slices.Clone(first), func(key string) bool {
+		return key == "default" || strings.Count(key, ":") != 1 || strings.HasPrefix(key, "again:")
+	}))
+}
+
+func TestReaderTimings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte("version: '3'\nincludes:\n  lib: ./lib.yml\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.yml"), []byte("version: '3'\n"), 0o644))
+
+	var mu sync.Mutex
+	var debug strings.Builder
+	node, err := NewFileNode(filepath.Join(dir, "Taskfile.yml"), dir)
+	require.NoError(t, err)
+	_, err = NewReader(WithDebugFunc(func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		debug.WriteString(s)
+	})).Read(t.Context(), node)
+	require.NoError(t, err)
+
+	assert.Contains(t, debug.String(), "read 2 Taskfiles in ")
+	assert.Contains(t, debug.String(), filepath.Join(dir, "lib.yml")+": read in ")
+}
@@ -0,0 +1,113 @@
+package taskfile
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+// signatureExtension is the extension of the detached signature that is
+// fetched alongside a remote Taskfile.
+const signatureExtension = ".minisig"
+
+const (
+	// algorithmPureEd25519 signs the content directly.
+	algorithmPureEd25519 = "Ed"
+	// algorithmHashedEd25519 signs the BLAKE2b-512 hash of the content.
+	algorithmHashedEd25519 = "ED"
+
+	untrustedCommentPrefix = "untrusted comment:"
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+// A publicKey is a minisign public key that signatures are verified with.
+type publicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// parsePublicKeys parses the given minisign public keys. Each key can either
+// be the base64 encoded key or the content of a minisign public key file.
+func parsePublicKeys(keys []string) ([]*publicKey, error) {
+	publicKeys := make([]*publicKey, 0, len(keys))
+	for _, key := range keys {
+		publicKey, err := parsePublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+	return publicKeys, nil
+}
+
+func parsePublicKey(s string) (*publicKey, error) {
+	var encoded string
+	for line := range strings.Lines(s) {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, untrustedCommentPrefix) {
+			encoded = line
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != algorithmPureEd25519 {
+		return nil, fmt.Errorf("task: Invalid trusted key %q", strings.TrimSpace(s))
+	}
+	pk := &publicKey{key: ed25519.PublicKey(b[10:])}
+	copy(pk.id[:], b[2:10])
+	return pk, nil
+}
+
+// verifySignature checks that the given minisign signature of the content was
+// made by one of the given keys.
+func verifySignature(keys []*publicKey, content, signature []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return errors.New("malformed signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("malformed signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("malformed signature")
+	}
+
+	var key *publicKey
+	for _, k := range keys {
+		if bytes.Equal(k.id[:], sig[2:10]) {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("signed with untrusted key %X", sig[2:10])
+	}
+
+	message := content
+	switch string(sig[:2]) {
+	case algorithmPureEd25519:
+	case algorithmHashedEd25519:
+		hash := blake2b.Sum512(content)
+		message = hash[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", sig[:2])
+	}
+	if !ed25519.Verify(key.key, message, sig[10:]) {
+		return errors.New("invalid signature")
+	}
+
+	// The trusted comment is signed along with the signature itself
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), trustedCommentPrefix)
+	if !ed25519.Verify(key.key, slices.Concat(sig[10:], []byte(trustedComment)), globalSig) {
+		return errors.New("invalid trusted comment signature")
+	}
+	return nil
+}
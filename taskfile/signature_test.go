@@ -0,0 +1,178 @@
+package taskfile
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/vikbert/taskr/v3/errors"
+)
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("version: '3'\n")
+	trusted, trustedKey := newMinisignKey(t, 1)
+	untrusted, _ := newMinisignKey(t, 2)
+	keys, err := parsePublicKeys([]string{trustedKey})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		signature []byte
+		content   []byte
+		wantErr   string
+	}{
+		{
+			name:      "pure",
+			signature: signMinisign(trusted, 1, algorithmPureEd25519, content),
+			content:   content,
+		},
+		{
+			name:      "hashed",
+			signature: signMinisign(trusted, 1, algorithmHashedEd25519, content),
+			content:   content,
+		},
+		{
+			name:      "tampered content",
+			signature: signMinisign(trusted, 1, algorithmHashedEd25519, content),
+			content:   []byte("version: '3'\ntasks: {}\n"),
+			wantErr:   "invalid signature",
+		},
+		{
+			name:      "untrusted key",
+			signature: signMinisign(untrusted, 2, algorithmHashedEd25519, content),
+			content:   content,
+			wantErr:   "signed with untrusted key",
+		},
+		{
+			name:      "malformed",
+			signature: []byte("not a signature"),
+			content:   content,
+			wantErr:   "malformed signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := verifySignature(keys, tt.content, tt.signature)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReaderSignedRemote(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("version: '3'\n")
+	priv, key := newMinisignKey(t, 1)
+	signature := signMinisign(priv, 1, algorithmHashedEd25519, content)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/signed/Taskfile.yml", serveYAML(content))
+	mux.HandleFunc("/signed/Taskfile.yml.minisig", serveYAML(signature))
+	mux.HandleFunc("/unsigned/Taskfile.yml", serveYAML(content))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	read := func(t *testing.T, tempDir string, path string, offline bool) error {
+		t.Helper()
+		node, err := NewHTTPNode(srv.URL+path, "", true)
+		require.NoError(t, err)
+		r := NewReader(
+			WithInsecure(true),
+			WithOffline(offline),
+			WithTempDir(tempDir),
+			WithTrustedKeys([]string{key}),
+			WithCacheExpiryDuration(time.Hour),
+		)
+		_, err = r.Read(t.Context(), node)
+		return err
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		t.Parallel()
+
+		tempDir := t.TempDir()
+		require.NoError(t, read(t, tempDir, "/signed/Taskfile.yml", false))
+
+		// The cached copy is verified again when it is used
+		node, err := NewHTTPNode(srv.URL+"/signed/Taskfile.yml", "", true)
+		require.NoError(t, err)
+		require.NoError(t, NewCacheNode(node, tempDir).Write([]byte("version: '3'\ntasks: {}\n")))
+		var sigErr *errors.TaskfileSignatureError
+		require.ErrorAs(t, read(t, tempDir, "/signed/Taskfile.yml", true), &sigErr)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		t.Parallel()
+
+		var sigErr *errors.TaskfileSignatureError
+		require.ErrorAs(t, read(t, t.TempDir(), "/unsigned/Taskfile.yml", false), &sigErr)
+	})
+}
+
+func serveYAML(b []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/yaml")
+		_, _ = w.Write(b)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+
+	_, key := newMinisignKey(t, 1)
+	_, err := parsePublicKey("untrusted comment: minisign public key 1\n" + key + "\n")
+	require.NoError(t, err)
+	_, err = parsePublicKey("invalid")
+	require.Error(t, err)
+}
+
+// newMinisignKey returns a new private key and the matching public key in the
+// minisign format, with the given key ID.
+func newMinisignKey(t *testing.T, id byte) (ed25519.PrivateKey, string) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	b := slices.Concat([]byte(algorithmPureEd25519), minisignKeyID(id), pub)
+	return priv, base64.StdEncoding.EncodeToString(b)
+}
+
+// signMinisign returns a minisign signature of the given content.
+func signMinisign(priv ed25519.PrivateKey, id byte, algorithm string, content []byte) []byte {
+	message := content
+	if algorithm == algorithmHashedEd25519 {
+		hash := blake2b.Sum512(content)
+		message = hash[:]
+	}
+	sig := ed25519.Sign(priv, message)
+	trustedComment := "timestamp:0"
+	globalSig := ed25519.Sign(priv, slices.Concat(sig, []byte(trustedComment)))
+	return fmt.Appendf(nil, "untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(slices.Concat([]byte(algorithm), minisignKeyID(id), sig)),
+		trustedComment,
+		base64.StdEncoding.EncodeToString(globalSig),
+	)
+}
+
+func minisignKeyID(id byte) []byte {
+	return []byte{id, 0, 0, 0, 0, 0, 0, 0}
+}
@@ -39,11 +39,20 @@ var (
 // these match a file, the first matching path will be returned. If no files are
 // found, an error will be returned.
 func RemoteExists(ctx context.Context, u url.URL) (*url.URL, error) {
+	return remoteExists(ctx, u, nil)
+}
+
+// remoteExists works like [RemoteExists] but authenticates the requests with
+// the given auth.
+func remoteExists(ctx context.Context, u url.URL, auth map[string]*Auth) (*url.URL, error) {
 	// Create a new HEAD request for the given URL to check if the resource exists
 	req, err := http.NewRequestWithContext(ctx, "HEAD", u.String(), nil)
 	if err != nil {
 		return nil, errors.TaskfileFetchFailedError{URI: u.Redacted()}
 	}
+	if err := authorize(ctx, auth, req); err != nil {
+		return nil, err
+	}
 
 	// Request the given URL
 	resp, err := http.DefaultClient.Do(req)
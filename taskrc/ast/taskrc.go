@@ -16,17 +16,55 @@ type TaskRC struct {
 	DisableFuzzy *bool           `yaml:"disable-fuzzy"`
 	Concurrency  *int            `yaml:"concurrency"`
 	Remote       Remote          `yaml:"remote"`
+	Shutdown     Shutdown        `yaml:"shutdown"`
+	Container    Container       `yaml:"container"`
+	Hosts        map[string]Host `yaml:"hosts"`
 	Failfast     bool            `yaml:"failfast"`
+	Strict       *bool           `yaml:"strict"`
 	Experiments  map[string]int  `yaml:"experiments"`
 }
 
 type Remote struct {
-	Insecure     *bool          `yaml:"insecure"`
-	Offline      *bool          `yaml:"offline"`
-	Timeout      *time.Duration `yaml:"timeout"`
-	CacheExpiry  *time.Duration `yaml:"cache-expiry"`
-	CacheDir     *string        `yaml:"cache-dir"`
-	TrustedHosts []string       `yaml:"trusted-hosts"`
+	Insecure     *bool                 `yaml:"insecure"`
+	Offline      *bool                 `yaml:"offline"`
+	Timeout      *time.Duration        `yaml:"timeout"`
+	CacheExpiry  *time.Duration        `yaml:"cache-expiry"`
+	CacheDir     *string               `yaml:"cache-dir"`
+	TrustedHosts []string              `yaml:"trusted-hosts"`
+	TrustedKeys  []string              `yaml:"trusted-keys"`
+	Auth         map[string]RemoteAuth `yaml:"auth"`
+}
+
+type RemoteAuth struct {
+	TokenEnv         string `yaml:"token-env"`
+	Username         string `yaml:"username"`
+	PasswordEnv      string `yaml:"password-env"`
+	Netrc            bool   `yaml:"netrc"`
+	CredentialHelper string `yaml:"credential-helper"`
+}
+
+type Shutdown struct {
+	Escalation   []ShutdownStep `yaml:"escalation"`
+	DeferTimeout *time.Duration `yaml:"defer-timeout"`
+}
+
+type Container struct {
+	Runtime *string `yaml:"runtime"`
+}
+
+type Host struct {
+	Address      string `yaml:"address"`
+	Port         int    `yaml:"port"`
+	User         string `yaml:"user"`
+	IdentityFile string `yaml:"identity-file"`
+	KnownHosts   string `yaml:"known-hosts"`
+	Insecure     bool   `yaml:"insecure"`
+	Dir          string `yaml:"dir"`
+}
+
+type ShutdownStep struct {
+	Signal string        `yaml:"signal"`
+	After  time.Duration `yaml:"after"`
 }
 
 // Merge combines the current TaskRC with another TaskRC, prioritizing non-nil fields from the other TaskRC.
@@ -54,10 +92,35 @@ func (t *TaskRC) Merge(other *TaskRC) {
 		slices.Sort(merged)
 		t.Remote.TrustedHosts = slices.Compact(merged)
 	}
+	if len(other.Remote.TrustedKeys) > 0 {
+		merged := slices.Concat(other.Remote.TrustedKeys, t.Remote.TrustedKeys)
+		slices.Sort(merged)
+		t.Remote.TrustedKeys = slices.Compact(merged)
+	}
+	if t.Remote.Auth == nil && other.Remote.Auth != nil {
+		t.Remote.Auth = other.Remote.Auth
+	} else if t.Remote.Auth != nil && other.Remote.Auth != nil {
+		maps.Copy(t.Remote.Auth, other.Remote.Auth)
+	}
+
+	// Merge Shutdown fields
+	if len(other.Shutdown.Escalation) > 0 {
+		t.Shutdown.Escalation = other.Shutdown.Escalation
+	}
+	t.Shutdown.DeferTimeout = cmp.Or(other.Shutdown.DeferTimeout, t.Shutdown.DeferTimeout)
+
+	if t.Hosts == nil && other.Hosts != nil {
+		t.Hosts = other.Hosts
+	} else if t.Hosts != nil && other.Hosts != nil {
+		maps.Copy(t.Hosts, other.Hosts)
+	}
+
+	t.Container.Runtime = cmp.Or(other.Container.Runtime, t.Container.Runtime)
 
 	t.Verbose = cmp.Or(other.Verbose, t.Verbose)
 	t.Color = cmp.Or(other.Color, t.Color)
 	t.DisableFuzzy = cmp.Or(other.DisableFuzzy, t.DisableFuzzy)
 	t.Concurrency = cmp.Or(other.Concurrency, t.Concurrency)
 	t.Failfast = cmp.Or(other.Failfast, t.Failfast)
+	t.Strict = cmp.Or(other.Strict, t.Strict)
 }
@@ -307,3 +307,39 @@ remote:
 		assert.Equal(t, []string{"github.com", "gitlab.com"}, base.Remote.TrustedHosts)
 	})
 }
+
+func TestGetConfig_Shutdown(t *testing.T) { //nolint:paralleltest // cannot run in parallel
+	_, homeDir, localDir := setupDirs(t)
+
+	homeConfig := `
+shutdown:
+  escalation:
+    - signal: SIGINT
+    - signal: SIGTERM
+      after: 10s
+  defer-timeout: 1m
+`
+	writeFile(t, homeDir, ".taskrc.yml", homeConfig)
+
+	localConfig := `
+shutdown:
+  escalation:
+    - signal: SIGTERM
+    - signal: SIGKILL
+      after: 5s
+`
+	writeFile(t, localDir, ".taskrc.yml", localConfig)
+
+	cfg, err := GetConfig(localDir)
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	deferTimeout := time.Minute
+	assert.Equal(t, ast.Shutdown{
+		Escalation: []ast.ShutdownStep{
+			{Signal: "SIGTERM"},
+			{Signal: "SIGKILL", After: 5 * time.Second},
+		},
+		DeferTimeout: &deferTimeout,
+	}, cfg.Shutdown)
+}
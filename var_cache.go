@@ -0,0 +1,211 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/vikbert/taskr/v3/internal/fingerprint"
+	"github.com/vikbert/taskr/v3/taskfile/ast"
+)
+
+// cachedVar is the result of a dynamic variable that is cached between runs
+type cachedVar struct {
+	Command  string    `json:"command"`
+	Value    string    `json:"value"`
+	Created  time.Time `json:"created"`
+	Checksum string    `json:"checksum,omitempty"`
+}
+
+// cachedVarPath returns the path of the cached result of the given command.
+// Besides the command and the directory it runs in, the result depends on the
+// environment variables that the command references, such as $VERSION. Those
+// that are only read by the programs it runs are not part of the key.
+func (c *Compiler) cachedVarPath(command, dir string, environ []string) string {
+	h := sha256.New()
+	h.Write([]byte(dir + "\x00" + command))
+	for _, kv := range referencedEnv(command, environ) {
+		h.Write([]byte("\x00" + kv))
+	}
+	return filepath.Join(c.CacheDir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// referencedEnv returns the variables of the environment that the given
+// command references, sorted by name
+func referencedEnv(command string, environ []string) []string {
+	names := shellParams(command)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	// The variables set last take precedence
+	values := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			values[name] = value
+		}
+	}
+	kvs := make([]string, 0, len(names))
+	for _, name := range names {
+		if value, ok := values[name]; ok {
+			kvs = append(kvs, name+"="+value)
+		}
+	}
+	return kvs
+}
+
+// checkCachedVars refuses the cached dynamic variables whose command uses a
+// secret variable, since its output would likely contain the secret. Unlike
+// the check on the output, it doesn't depend on which secrets were resolved.
+func checkCachedVars(tf *ast.Taskfile) error {
+	secrets := map[string]bool{}
+	for name, v := range taskfileVars(tf) {
+		if v.Secret != nil {
+			secrets[name] = true
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	for name, v := range taskfileVars(tf) {
+		if v.Cache == nil || v.Sh == nil {
+			continue
+		}
+		for _, ref := range referencedVars(*v.Sh) {
+			if secrets[ref] {
+				return fmt.Errorf("task: Variable %q can't be cached since its command uses the secret variable %q", name, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// taskfileVars returns the variables and environment variables declared in
+// the Taskfile, its profiles and its tasks
+func taskfileVars(tf *ast.Taskfile) iter.Seq2[string, ast.Var] {
+	return func(yield func(string, ast.Var) bool) {
+		all := []*ast.Vars{tf.Vars, tf.Env}
+		for _, profile := range tf.Profiles {
+			if profile != nil {
+				all = append(all, profile.Vars, profile.Env)
+			}
+		}
+		for t := range tf.Tasks.Values(nil) {
+			if t != nil {
+				all = append(all, t.Vars, t.Env, t.IncludeVars)
+			}
+		}
+		for _, vars := range all {
+			for name, v := range vars.All() {
+				if !yield(name, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// templateFieldRegexp matches the variables that the actions of a template
+// use, such as .VERSION
+var templateFieldRegexp = regexp.MustCompile(`\.([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// referencedVars returns the variables that the given command uses, either in
+// its templates or as shell parameters
+func referencedVars(command string) []string {
+	var names []string
+	for rest := command; ; {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			break
+		}
+		for _, match := range templateFieldRegexp.FindAllStringSubmatch(rest[start:start+end], -1) {
+			names = append(names, match[1])
+		}
+		rest = rest[start+end:]
+	}
+	return append(names, shellParams(command)...)
+}
+
+// shellParams returns the parameters that the given command expands, such as
+// $VERSION
+func shellParams(command string) []string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if param, ok := node.(*syntax.ParamExp); ok && param.Param != nil {
+			names = append(names, param.Param.Value)
+		}
+		return true
+	})
+	return names
+}
+
+// readCachedVar returns the cached result of the dynamic variable if it is
+// still valid: it is younger than the TTL and its sources did not change.
+func (c *Compiler) readCachedVar(v ast.Var, dir string, environ []string) (string, bool) {
+	if c.CacheDir == "" {
+		return "", false
+	}
+	b, err := os.ReadFile(c.cachedVarPath(*v.Sh, dir, environ))
+	if err != nil {
+		return "", false
+	}
+	var cached cachedVar
+	if err := json.Unmarshal(b, &cached); err != nil || cached.Command != *v.Sh {
+		return "", false
+	}
+	if v.Cache.TTL > 0 && time.Since(cached.Created) > v.Cache.TTL {
+		return "", false
+	}
+	if len(v.Cache.Sources) > 0 {
+		checksum, err := fingerprint.Checksum(dir, v.Cache.Sources)
+		if err != nil || checksum != cached.Checksum {
+			return "", false
+		}
+	}
+	return cached.Value, true
+}
+
+// writeCachedVar caches the result of the dynamic variable. The file is only
+// readable by the user since results can be sensitive.
+func (c *Compiler) writeCachedVar(v ast.Var, dir string, environ []string, value string) error {
+	if c.CacheDir == "" {
+		return nil
+	}
+	cached := cachedVar{
+		Command: *v.Sh,
+		Value:   value,
+		Created: time.Now(),
+	}
+	if len(v.Cache.Sources) > 0 {
+		checksum, err := fingerprint.Checksum(dir, v.Cache.Sources)
+		if err != nil {
+			return err
+		}
+		cached.Checksum = checksum
+	}
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.CacheDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.cachedVarPath(*v.Sh, dir, environ), b, 0o600)
+}
@@ -3,18 +3,17 @@ package task
 import (
 	"fmt"
 	"maps"
-	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
-	"github.com/joho/godotenv"
-
 	"github.com/vikbert/taskr/v3/errors"
 	"github.com/vikbert/taskr/v3/internal/env"
 	"github.com/vikbert/taskr/v3/internal/execext"
 	"github.com/vikbert/taskr/v3/internal/filepathext"
 	"github.com/vikbert/taskr/v3/internal/fingerprint"
 	"github.com/vikbert/taskr/v3/internal/templater"
+	"github.com/vikbert/taskr/v3/taskfile"
 	"github.com/vikbert/taskr/v3/taskfile/ast"
 )
 
@@ -40,7 +39,7 @@ func (e *Executor) CompiledTaskForTaskList(call *Call) (*ast.Task, error) {
 		return nil, err
 	}
 
-	cache := &templater.Cache{Vars: vars}
+	cache := &templater.Cache{Vars: vars, Dir: e.Dir}
 
 	return &ast.Task{
 		Task:                 origTask.Task,
@@ -68,11 +67,17 @@ func (e *Executor) CompiledTaskForTaskList(call *Call) (*ast.Task, error) {
 		IncludeVars:          origTask.IncludeVars,
 		IncludedTaskfileVars: origTask.IncludedTaskfileVars,
 		Platforms:            origTask.Platforms,
+		Container:            origTask.Container,
+		Interpreter:          origTask.Interpreter,
+		Hosts:                origTask.Hosts,
+		Uses:                 origTask.Uses,
+		Log:                  origTask.Log,
 		Location:             origTask.Location,
 		Requires:             origTask.Requires,
 		Watch:                origTask.Watch,
 		Namespace:            origTask.Namespace,
 		Failfast:             origTask.Failfast,
+		Strict:               origTask.Strict,
 		Index:                origTask.Index,
 	}, nil
 }
@@ -90,7 +95,7 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 		vars, err = e.Compiler.FastGetVariables(origTask, call)
 	}
 	if err != nil {
-		return nil, err
+		return nil, withTaskLocation(err, origTask)
 	}
 	fullName := origTask.Task
 	if matches, exists := vars.Get("MATCH"); exists {
@@ -99,7 +104,17 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 		}
 	}
 
-	cache := &templater.Cache{Vars: vars}
+	// The templates of the task resolve relative paths in its directory
+	cache := &templater.Cache{Vars: vars, Strict: e.Strict || origTask.Strict, Dir: e.Dir}
+	dir, err := execext.ExpandLiteral(templater.Replace(origTask.Dir, cache))
+	if err != nil {
+		return nil, err
+	}
+	if e.Dir != "" {
+		dir = filepathext.SmartJoin(e.Dir, dir)
+	}
+	cache.Dir = dir
+
 	new := ast.Task{
 		Task:                 origTask.Task,
 		Label:                templater.Replace(origTask.Label, cache),
@@ -109,7 +124,7 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 		Aliases:              origTask.Aliases,
 		Sources:              templater.ReplaceGlobs(origTask.Sources, cache),
 		Generates:            templater.ReplaceGlobs(origTask.Generates, cache),
-		Dir:                  templater.Replace(origTask.Dir, cache),
+		Dir:                  dir,
 		Set:                  origTask.Set,
 		Shopt:                origTask.Shopt,
 		Vars:                 vars,
@@ -125,41 +140,37 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 		IncludeVars:          origTask.IncludeVars,
 		IncludedTaskfileVars: origTask.IncludedTaskfileVars,
 		Platforms:            origTask.Platforms,
+		Interpreter:          templater.Replace(origTask.Interpreter, cache),
+		Hosts:                templater.Replace(origTask.Hosts, cache),
+		Uses:                 origTask.Uses,
+		Log:                  origTask.Log.DeepCopy(),
 		Location:             origTask.Location,
 		Requires:             origTask.Requires,
 		Watch:                origTask.Watch,
 		Failfast:             origTask.Failfast,
+		Strict:               origTask.Strict,
 		Namespace:            origTask.Namespace,
 		FullName:             fullName,
 	}
-	new.Dir, err = execext.ExpandLiteral(new.Dir)
-	if err != nil {
-		return nil, err
-	}
-	if e.Dir != "" {
-		new.Dir = filepathext.SmartJoin(e.Dir, new.Dir)
-	}
 	if new.Prefix == "" {
 		new.Prefix = new.Task
 	}
+	if new.Log != nil {
+		new.Log.Dir = templater.Replace(new.Log.Dir, cache)
+	}
 
-	dotenvEnvs := ast.NewVars()
-	if len(new.Dotenv) > 0 {
-		for _, dotEnvPath := range new.Dotenv {
-			dotEnvPath = filepathext.SmartJoin(new.Dir, dotEnvPath)
-			if _, err := os.Stat(dotEnvPath); os.IsNotExist(err) {
-				continue
-			}
-			envs, err := godotenv.Read(dotEnvPath)
-			if err != nil {
-				return nil, err
-			}
-			for key, value := range envs {
-				if _, ok := dotenvEnvs.Get(key); !ok {
-					dotenvEnvs.Set(key, ast.Var{Value: value})
-				}
-			}
+	// The dotenv files of the task take precedence over those of the
+	// Taskfile that included it
+	dotenvPaths := make([]string, 0, len(new.Dotenv)+len(origTask.IncludedTaskfileDotenv))
+	for _, dotEnvPath := range slices.Concat(new.Dotenv, templater.Replace(origTask.IncludedTaskfileDotenv, cache)) {
+		if dotEnvPath == "" {
+			continue
 		}
+		dotenvPaths = append(dotenvPaths, filepathext.SmartJoin(new.Dir, dotEnvPath))
+	}
+	dotenvEnvs, err := taskfile.ReadDotenvFiles(dotenvPaths, e.Environment)
+	if err != nil {
+		return nil, err
 	}
 
 	new.Env = ast.NewVars()
@@ -168,6 +179,14 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 	new.Env.Merge(templater.ReplaceVars(origTask.Env, cache), nil)
 	if evaluateShVars {
 		for k, v := range new.Env.All() {
+			if v.Secret != nil {
+				static, err := e.Compiler.HandleSecretVar(v, new.Dir, env.GetFromVars(new.Env))
+				if err != nil {
+					return nil, err
+				}
+				new.Env.Set(k, ast.Var{Value: static})
+				continue
+			}
 			// If the variable is not dynamic, we can set it and return
 			if v.Value != nil || v.Sh == nil {
 				new.Env.Set(k, ast.Var{Value: v.Value})
@@ -181,6 +200,35 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 		}
 	}
 
+	if origTask.Container != nil {
+		new.Container = &ast.Container{
+			Image:   templater.Replace(origTask.Container.Image, cache),
+			Volumes: templater.Replace(origTask.Container.Volumes, cache),
+			Env:     templater.ReplaceVars(origTask.Container.Env, cache),
+			Workdir: templater.Replace(origTask.Container.Workdir, cache),
+		}
+		if evaluateShVars {
+			for k, v := range new.Container.Env.All() {
+				if v.Secret != nil {
+					static, err := e.Compiler.HandleSecretVar(v, new.Dir, env.GetFromVars(new.Env))
+					if err != nil {
+						return nil, err
+					}
+					new.Container.Env.Set(k, ast.Var{Value: static})
+					continue
+				}
+				if v.Value != nil || v.Sh == nil {
+					continue
+				}
+				static, err := e.Compiler.HandleDynamicVar(v, new.Dir, env.GetFromVars(new.Env))
+				if err != nil {
+					return nil, err
+				}
+				new.Container.Env.Set(k, ast.Var{Value: static})
+			}
+		}
+	}
+
 	if len(origTask.Sources) > 0 && origTask.Method != "none" {
 		var checker fingerprint.SourcesCheckable
 
@@ -229,6 +277,7 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 					}
 					newCmd := cmd.DeepCopy()
 					newCmd.Cmd = templater.ReplaceWithExtra(cmd.Cmd, cache, extra)
+					newCmd.Interpreter = templater.ReplaceWithExtra(cmd.Interpreter, cache, extra)
 					newCmd.Task = templater.ReplaceWithExtra(cmd.Task, cache, extra)
 					newCmd.Vars = templater.ReplaceVarsWithExtra(cmd.Vars, cache, extra)
 					new.Cmds = append(new.Cmds, newCmd)
@@ -243,6 +292,7 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 			}
 			newCmd := cmd.DeepCopy()
 			newCmd.Cmd = templater.Replace(cmd.Cmd, cache)
+			newCmd.Interpreter = templater.Replace(cmd.Interpreter, cache)
 			newCmd.Task = templater.Replace(cmd.Task, cache)
 			newCmd.Vars = templater.ReplaceVars(cmd.Vars, cache)
 			new.Cmds = append(new.Cmds, newCmd)
@@ -307,12 +357,25 @@ func (e *Executor) compiledTask(call *Call, evaluateShVars bool) (*ast.Task, err
 
 	// We only care about templater errors if we are evaluating shell variables
 	if evaluateShVars && cache.Err() != nil {
-		return &new, cache.Err()
+		return &new, withTaskLocation(cache.Err(), origTask)
 	}
 
 	return &new, nil
 }
 
+// withTaskLocation adds the name and the location of the given task to errors
+// about undefined variables.
+func withTaskLocation(err error, t *ast.Task) error {
+	var undefinedVarErr *errors.TaskUndefinedVarError
+	if errors.As(err, &undefinedVarErr) && undefinedVarErr.TaskName == "" {
+		undefinedVarErr.TaskName = t.Task
+		if t.Location != nil {
+			undefinedVarErr.Location = fmt.Sprintf("%s:%d:%d", t.Location.Taskfile, t.Location.Line, t.Location.Column)
+		}
+	}
+	return err
+}
+
 func asAnySlice[T any](slice []T) []any {
 	ret := make([]any, len(slice))
 	for i, v := range slice {
@@ -27,6 +27,7 @@ const defaultWaitTime = 100 * time.Millisecond
 
 // watchTasks start watching the given tasks
 func (e *Executor) watchTasks(calls ...*Call) error {
+	calls = parallelCalls(calls)
 	tasks := make([]string, len(calls))
 	for i, c := range calls {
 		tasks[i] = c.Task